@@ -1,9 +1,11 @@
 package cli
 
 import (
-	"fmt"
+	"context"
+	"io"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 // App is the main structure of a cli application
@@ -32,24 +34,84 @@ type App struct {
 	Flags []*Flag
 	// List of commands to execute
 	Commands []*Command
+	// List of positional arguments, only used when no subcommands are specified
+	Arguments []*Argument
+	// Constraints of flags, e.g. mutually exclusive flags
+	FlagGroups []*FlagGroup
 
 	// Hidden --help and --version from usage
 	HiddenHelp    bool
 	HiddenVersion bool
 
+	// Allow unambiguous prefix of long flag name, e.g. --verb for --verbose
+	AllowAbbrev bool
+
+	// Disable "did you mean" suggestions for unknown commands and flags
+	DisableSuggestions bool
+	// Max edit distance for suggestions. Defaults to 2
+	SuggestionDistance int
+	// Include hidden commands and flags in suggestions
+	SuggestHidden bool
+
+	// Name of flag to specify the config file, e.g. "config", the flag is added if not exists.
+	// The format of config file is determined by the file extension, see ConfigParsers
+	ConfigFlag string
+	// Search paths of config file if it is not specified by ConfigFlag, the first existing one is used
+	ConfigPaths []string
+	// Other sources of flag values, they have lower precedence than config file
+	ValueSources []ValueSource
+
+	// Panic if an undeclared flag name is used in Context.GetXxx() and Context.IsSet(), for development
+	Strict bool
+
+	// Dump the value and source of every flag to Stderr before validating flags and running action
+	DebugFlags bool
+
+	// Add a "completion SHELL" command to generate shell completion script
+	EnableCompletion bool
+
 	// Display full help
 	ShowHelp func(*HelpContext)
 	// Display full version
 	ShowVersion func(*App)
 
+	// Executed before any subcommands or action, the execution is aborted if it returns an error.
+	// If the action runs, its flags and arguments are validated before Before,
+	// but the flags of subcommands are not parsed yet.
+	Before func(*Context) error
+	// Executed after any subcommands or action, even if they or Before return an error or panic
+	After func(*Context) error
+
 	// The action to execute when no subcommands are specified
 	Action func(*Context)
+	// Same as Action, but returns an error. It is used in preference to Action if set
+	ActionE func(*Context) error
+
+	// Returns the candidates of positional arguments for shell completion,
+	// a candidate can be "value" or "value\tdescription"
+	Complete func(c *Context, toComplete string) []string
 
 	// Execute this function if the proper command cannot be found
 	OnCommandNotFound func(*Context, string)
 
 	// Handler if panic in app.Action() and command.Action()
 	OnActionPanic func(*Context, error)
+
+	// Standard streams of the program. Defaults to os.Stdin, os.Stdout and os.Stderr
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// The function to exit the program. Defaults to os.Exit
+	Exit func(code int)
+
+	// Cancel the context on SIGINT or SIGTERM, and exit on the second signal
+	HandleSignals bool
+	// Exit if the action does not return in the grace period after the context is cancelled by signal,
+	// it waits for the action or the second signal if zero
+	SignalGracePeriod time.Duration
+
+	middlewares []Middleware // see Use()
 }
 
 // NewApp creates a new cli Application
@@ -60,63 +122,142 @@ func NewApp() *App {
 		Version:     "0.0.0",
 		ShowHelp:    showHelp,
 		ShowVersion: showVersion,
+		Stdin:       os.Stdin,
+		Stdout:      os.Stdout,
+		Stderr:      os.Stderr,
+		Exit:        os.Exit,
 	}
 }
 
 func (a *App) initialize() {
-	// add --help
-	a.Flags = append(a.Flags, &Flag{
-		Name:   "help",
-		Usage:  "print this usage",
-		IsBool: true,
-		Hidden: a.HiddenHelp,
-	})
+	if a.Stdin == nil {
+		a.Stdin = os.Stdin
+	}
+	if a.Stdout == nil {
+		a.Stdout = os.Stdout
+	}
+	if a.Stderr == nil {
+		a.Stderr = os.Stderr
+	}
+	if a.Exit == nil {
+		a.Exit = os.Exit
+	}
+
+	if a.ShowHelp == nil {
+		a.ShowHelp = showHelp
+	}
+	if a.ShowVersion == nil {
+		a.ShowVersion = showVersion
+	}
+
+	// add --help, only once if run multiple times
+	if lookupFlag(a.Flags, "help") == nil {
+		a.Flags = append(a.Flags, &Flag{
+			Name:   "help",
+			Usage:  "print this usage",
+			IsBool: true,
+			Hidden: a.HiddenHelp,
+		})
+	}
 	// add --version
-	a.Flags = append(a.Flags, &Flag{
-		Name:   "version",
-		Usage:  "print version information",
-		IsBool: true,
-		Hidden: a.HiddenVersion,
-	})
+	if lookupFlag(a.Flags, "version") == nil {
+		a.Flags = append(a.Flags, &Flag{
+			Name:   "version",
+			Usage:  "print version information",
+			IsBool: true,
+			Hidden: a.HiddenVersion,
+		})
+	}
+
+	// add --config
+	if a.ConfigFlag != "" && lookupFlag(a.Flags, a.ConfigFlag) == nil {
+		a.Flags = append(a.Flags, &Flag{
+			Name:        a.ConfigFlag,
+			Usage:       "load options from config file",
+			Placeholder: "file",
+		})
+	}
+
+	// add completion command
+	if a.EnableCompletion && lookupCommand(a.Commands, "completion") == nil {
+		a.Commands = append(a.Commands, newCompletionCommand(a))
+	}
 
 	// initialize flags
 	for _, f := range a.Flags {
 		f.initialize()
 	}
+
+	initializeArguments(a.Arguments)
+	initializeFlagGroups(a.FlagGroups, a.Flags, a.Commands)
 }
 
-// Run is the entry point to the cli app, parse argument and call Execute() or command.Execute()
+// Run is the entry point to the cli app, parse argument and call Execute() or command.Execute().
+// It shows the error and exits if failed.
 func (a *App) Run(arguments []string) {
+	if err := a.RunE(arguments); err != nil {
+		ctx := &Context{app: a}
+		ctx.handleError(err)
+	}
+}
+
+// RunE is like Run, but returns the error instead of calling os.Exit
+func (a *App) RunE(arguments []string) error {
+	return a.RunContext(context.Background(), arguments)
+}
+
+// RunContext is like RunE, ctx can be got by Context.Context() in actions, hooks and completion callbacks.
+// If HandleSignals is true, ctx is cancelled on SIGINT or SIGTERM.
+func (a *App) RunContext(ctx context.Context, arguments []string) (err error) {
 	a.initialize()
 
-	// parse cli arguments
-	cl := &commandline{
-		flags:    a.Flags,
-		commands: a.Commands,
+	if a.HandleSignals {
+		var stop func()
+		ctx, stop = a.notifySignals(ctx)
+		defer stop()
+	}
+
+	// invoked by shell completion scripts
+	if len(arguments) > 1 && arguments[1] == completeCommandName {
+		return a.runComplete(ctx, arguments[2:])
 	}
-	err := cl.parse(arguments[1:])
+
+	// parse cli arguments
+	cl := a.newCommandline(a.Flags, a.Commands)
+	err = cl.parse(arguments[1:])
 
 	// build context
 	newCtx := &Context{
-		name:     a.Name,
-		app:      a,
-		flags:    a.Flags,
-		commands: a.Commands,
-		args:     cl.args,
+		ctx:       ctx,
+		name:      a.Name,
+		app:       a,
+		flags:     a.Flags,
+		commands:  a.Commands,
+		arguments: a.Arguments,
+		args:      cl.args,
 	}
 
 	if err != nil {
-		newCtx.ShowError(err)
+		return &UsageError{Name: newCtx.name, Err: err}
 	}
 
 	// show --help
 	if newCtx.GetBool("help") {
-		newCtx.ShowHelpAndExit(0)
+		newCtx.ShowHelp()
+		return nil
 	}
 	// show --version
 	if newCtx.GetBool("version") {
 		a.ShowVersion(a)
-		os.Exit(0)
+		return nil
+	}
+
+	// load config file
+	if newCtx.sources, err = a.loadValueSources(newCtx); err != nil {
+		return err
+	}
+	if err := newCtx.applyValueSources(); err != nil {
+		return err
 	}
 
 	// command not found
@@ -124,22 +265,85 @@ func (a *App) Run(arguments []string) {
 		cmd := cl.args[0]
 		if a.OnCommandNotFound != nil {
 			a.OnCommandNotFound(newCtx, cmd)
-		} else {
-			newCtx.ShowError(fmt.Errorf("no such command: %s", cmd))
+			return nil
+		}
+		return &UsageError{Name: newCtx.name, Err: &CommandNotFoundError{
+			Name:        cmd,
+			Suggestions: a.suggestCommands(cmd, a.Commands),
+		}}
+	}
+
+	// validate flags and arguments of action before hooks
+	action := actionFunc(a.Action, a.ActionE)
+	if cl.command == nil && action != nil {
+		newCtx.debugFlags()
+		if err := newCtx.checkRequiredFlags(); err != nil {
+			return err
 		}
-		return
+		if err := newCtx.checkFlagGroups(); err != nil {
+			return err
+		}
+		if err := bindArguments(newCtx.arguments, newCtx.args); err != nil {
+			return &UsageError{Name: newCtx.name, Err: err}
+		}
+	}
+
+	// run hooks around subcommands and action, After is executed even if Before fails
+	defer newCtx.runAfter(a.After, &err)
+	if err := newCtx.runBefore(a.Before); err != nil {
+		return err
 	}
 
 	// run command
 	if cl.command != nil {
-		cl.command.Run(newCtx)
-		return
+		return cl.command.RunE(newCtx)
 	}
 
-	if a.Action != nil {
-		defer newCtx.handlePanic()
-		a.Action(newCtx)
-	} else {
-		newCtx.ShowHelpAndExit(0)
+	if action != nil {
+		return newCtx.runAction(action)
+	}
+
+	newCtx.ShowHelp()
+	return nil
+}
+
+// newCommandline creates a parser with options of app, app may be nil
+func (a *App) newCommandline(flags []*Flag, commands []*Command) *commandline {
+	cl := &commandline{
+		flags:    flags,
+		commands: commands,
+	}
+	if a != nil {
+		cl.allowAbbrev = a.AllowAbbrev
+		cl.suggestDistance = a.suggestionDistance()
+		cl.suggestHidden = a.SuggestHidden
+	}
+	return cl
+}
+
+func (a *App) suggestionDistance() int {
+	if a == nil || a.DisableSuggestions {
+		return 0
+	}
+	if a.SuggestionDistance > 0 {
+		return a.SuggestionDistance
+	}
+	return defaultSuggestionDistance
+}
+
+// suggestCommands returns the command names which are similar to the name, app may be nil
+func (a *App) suggestCommands(name string, commands []*Command) []string {
+	distance := a.suggestionDistance()
+	if distance <= 0 {
+		return nil
+	}
+
+	var candidates []string
+	for _, c := range commands {
+		if c.Hidden && !a.SuggestHidden {
+			continue
+		}
+		candidates = append(candidates, c.Names()...)
 	}
+	return suggest(name, candidates, distance)
 }
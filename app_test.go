@@ -1,6 +1,11 @@
 package cli
 
 import (
+	"bytes"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -68,13 +73,378 @@ func TestAppRunPanic(t *testing.T) {
 		OnActionPanic: func(*Context, error) {
 			run = true
 		},
+		Exit: func(int) {},
 	}
 
-	exit = func(int) {}
-
 	app.Run([]string{"app"})
 
 	if run == false {
 		t.Fatal("OnActionPanic not hit")
 	}
 }
+
+func TestAppRunE(t *testing.T) {
+	app := &App{
+		Name: "app",
+		Flags: []*Flag{
+			{Name: "debug", IsBool: true},
+		},
+		Commands: []*Command{
+			{
+				Name: "cmd",
+				Action: func(ctx *Context) {
+					panic("err")
+				},
+			},
+		},
+	}
+
+	var usageErr *UsageError
+	err := app.RunE([]string{"app", "--xxx"})
+	if !errors.As(err, &usageErr) || usageErr.Name != "app" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var notFoundErr *CommandNotFoundError
+	err = app.RunE([]string{"app", "xxx"})
+	if !errors.As(err, &notFoundErr) || notFoundErr.Name != "xxx" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var panicErr *PanicError
+	err = app.RunE([]string{"app", "cmd"})
+	if !errors.As(err, &panicErr) || panicErr.Value != "err" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestAppRunActionE(t *testing.T) {
+	app := &App{
+		Name: "app",
+		ActionE: func(ctx *Context) error {
+			return NewExitError("not found", 3)
+		},
+	}
+
+	code := 0
+	app.Stderr = new(bytes.Buffer)
+	app.Exit = func(c int) {
+		code = c
+	}
+
+	app.Run([]string{"app"})
+
+	if code != 3 {
+		t.Fatalf("exit code is wrong, got: %d", code)
+	}
+}
+
+func TestAppRunOutput(t *testing.T) {
+	tests := []struct {
+		args   []string
+		stdout string
+		stderr string
+		code   int
+	}{
+		{[]string{"app", "--version"}, "Version:    1.0.0", "", -1},
+		{[]string{"app", "--help"}, "USAGE:", "", -1},
+		{[]string{"app", "--xxx"}, "", "unrecognized option '--xxx'", 1},
+		{[]string{"app", "xxx"}, "", "no such command: xxx", 1},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			t.Parallel()
+
+			stdout := new(bytes.Buffer)
+			stderr := new(bytes.Buffer)
+			code := -1
+			app := NewApp()
+			app.Name = "app"
+			app.Version = "1.0.0"
+			app.Commands = []*Command{
+				{Name: "cmd"},
+			}
+			app.Stdout = stdout
+			app.Stderr = stderr
+			app.Exit = func(c int) {
+				code = c
+			}
+
+			app.Run(tt.args)
+
+			if !strings.Contains(stdout.String(), tt.stdout) {
+				t.Errorf("stdout is wrong, got: %q", stdout.String())
+			}
+			if !strings.Contains(stderr.String(), tt.stderr) {
+				t.Errorf("stderr is wrong, got: %q", stderr.String())
+			}
+			if code != tt.code {
+				t.Errorf("exit code is wrong, got: %d", code)
+			}
+		})
+	}
+}
+
+func TestAppRunRequiredFlags(t *testing.T) {
+	run := false
+	app := &App{
+		Name:   "app",
+		Stdout: new(bytes.Buffer),
+		Flags: []*Flag{
+			{Name: "u, user", Required: true},
+			{Name: "p", Required: true, EnvVar: "TEST_APP_PASSWORD"},
+		},
+		Commands: []*Command{
+			{
+				Name: "cmd",
+				Flags: []*Flag{
+					{Name: "f, file", Required: true},
+				},
+				Action: func(ctx *Context) {
+					run = true
+				},
+			},
+		},
+	}
+
+	var requiredErr *RequiredFlagsError
+	err := app.RunE([]string{"app", "cmd"})
+	if !errors.As(err, &requiredErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err.Error() != `required options '--file', '--user', '-p' are not set` {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// skip check for --help
+	err = app.RunE([]string{"app", "cmd", "--help"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	os.Setenv("TEST_APP_PASSWORD", "xxx")
+	defer os.Unsetenv("TEST_APP_PASSWORD")
+
+	err = app.RunE([]string{"app", "--user=root", "cmd", "-f", "file"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if run == false {
+		t.Fatal("no command run")
+	}
+}
+
+func TestAppRunPersistentFlags(t *testing.T) {
+	var debug bool
+	var config, name string
+	app := &App{
+		Name: "app",
+		Flags: []*Flag{
+			{Name: "d, debug", IsBool: true, Persistent: true},
+			{Name: "c, config", Persistent: true},
+		},
+		Commands: []*Command{
+			{
+				Name: "cmd",
+				Commands: []*Command{
+					{
+						Name: "sub",
+						Flags: []*Flag{
+							{Name: "name"},
+						},
+						Action: func(ctx *Context) {
+							debug = ctx.GetBool("debug")
+							config = ctx.GetString("config")
+							name = ctx.GetString("name")
+						},
+					},
+				},
+			},
+		},
+	}
+
+	err := app.RunE([]string{"app", "-c", "a.conf", "cmd", "sub", "--debug", "--name", "x"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if debug != true || config != "a.conf" || name != "x" {
+		t.Errorf("wrong flags: debug=%v, config=%v, name=%v", debug, config, name)
+	}
+}
+
+func TestAppRunSuggestions(t *testing.T) {
+	app := &App{
+		Name: "app",
+		Flags: []*Flag{
+			{Name: "verbose", IsBool: true},
+		},
+		Commands: []*Command{
+			{Name: "build"},
+			{Name: "secret", Hidden: true},
+		},
+	}
+
+	err := app.RunE([]string{"app", "biuld"})
+	if err == nil || err.Error() != "no such command: biuld\n\nDid you mean this?\n\tbuild" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = app.RunE([]string{"app", "secre"})
+	if err == nil || err.Error() != "no such command: secre" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = app.RunE([]string{"app", "--verbos"})
+	if err == nil || err.Error() != "unrecognized option '--verbos'\n\nDid you mean this?\n\t--verbose" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	app.DisableSuggestions = true
+	err = app.RunE([]string{"app", "biuld"})
+	if err == nil || err.Error() != "no such command: biuld" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestAppRunHooks(t *testing.T) {
+	var calls []string
+	hook := func(name string, err error) func(*Context) error {
+		return func(c *Context) error {
+			calls = append(calls, name)
+			return err
+		}
+	}
+
+	cmd := &Command{
+		Name:   "cmd",
+		Before: hook("cmd before", nil),
+		After:  hook("cmd after", nil),
+		ActionE: func(c *Context) error {
+			calls = append(calls, "action")
+			if c.NArg() > 0 {
+				panic("boom")
+			}
+			return nil
+		},
+	}
+	app := &App{
+		Name:          "app",
+		Before:        hook("app before", nil),
+		After:         hook("app after", nil),
+		Commands:      []*Command{cmd},
+		OnActionPanic: func(c *Context, err error) {},
+	}
+
+	// normal
+	if err := app.RunE([]string{"app", "cmd"}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"app before", "cmd before", "action", "cmd after", "app after"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+
+	// panic in action
+	calls = nil
+	err := app.RunE([]string{"app", "cmd", "panic"})
+	if _, ok := err.(*PanicError); !ok {
+		t.Errorf("wrong error: %v", err)
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+
+	// error in Before
+	calls = nil
+	cmd.Before = hook("cmd before", errors.New("before failed"))
+	err = app.RunE([]string{"app", "cmd"})
+	if err == nil || err.Error() != "before failed" {
+		t.Errorf("wrong error: %v", err)
+	}
+	want = []string{"app before", "cmd before", "cmd after", "app after"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+
+	// error in Before of app
+	calls = nil
+	app.Before = hook("app before", errors.New("before failed"))
+	err = app.RunE([]string{"app", "cmd"})
+	if err == nil || err.Error() != "before failed" {
+		t.Errorf("wrong error: %v", err)
+	}
+	want = []string{"app before", "app after"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+	app.Before = nil
+
+	// error in After
+	calls = nil
+	cmd.Before = nil
+	app.After = hook("app after", errors.New("after failed"))
+	err = app.RunE([]string{"app", "cmd"})
+	if err == nil || err.Error() != "after failed" {
+		t.Errorf("wrong error: %v", err)
+	}
+}
+
+func TestAppRunHooksAfterValidation(t *testing.T) {
+	var calls []string
+	var name string
+	cmd := &Command{
+		Name: "cmd",
+		Flags: []*Flag{
+			{Name: "jobs", Required: true},
+		},
+		Arguments: []*Argument{
+			{Name: "name", Value: &name},
+		},
+		Before: func(c *Context) error {
+			calls = append(calls, "cmd before: "+name)
+			return nil
+		},
+		After: func(c *Context) error {
+			calls = append(calls, "cmd after")
+			return nil
+		},
+		Action: func(c *Context) {
+			calls = append(calls, "action")
+		},
+	}
+	app := &App{
+		Name: "app",
+		Before: func(c *Context) error {
+			calls = append(calls, "app before")
+			return nil
+		},
+		After: func(c *Context) error {
+			calls = append(calls, "app after")
+			return nil
+		},
+		Commands: []*Command{cmd},
+	}
+
+	// required flag is missing
+	err := app.RunE([]string{"app", "cmd", "foo"})
+	var requiredErr *RequiredFlagsError
+	if !errors.As(err, &requiredErr) {
+		t.Fatalf("wrong error: %v", err)
+	}
+	want := []string{"app before", "app after"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+
+	// arguments are bound before hook
+	calls = nil
+	if err := app.RunE([]string{"app", "cmd", "--jobs", "4", "foo"}); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"app before", "cmd before: foo", "action", "cmd after", "app after"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+}
@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Argument represents a declared positional argument of app/command
+type Argument struct {
+	Name     string // name as it appears in usage
+	Usage    string // help message
+	Required bool   // the argument must be given in cli args
+	Variadic bool   // accepts all the remaining arguments, it must be the last one
+
+	Value interface{} // returns final value
+
+	wrapValue Value // returns final value, wrapped Argument.Value
+}
+
+func (a *Argument) initialize() {
+	if a.Value != nil {
+		a.wrapValue = newValue(a.Value)
+		if a.wrapValue == nil {
+			panic(fmt.Sprintf("unknown type of argument.Value: %T", a.Value))
+		}
+	}
+
+	if a.Value == nil {
+		if a.Variadic {
+			a.wrapValue = &stringSliceValue{new([]string)}
+		} else {
+			a.wrapValue = &stringValue{new(string)}
+		}
+	}
+}
+
+// GetValue returns the string value of argument
+func (a *Argument) GetValue() string {
+	return a.wrapValue.String()
+}
+
+func initializeArguments(arguments []*Argument) {
+	optional := false
+	for i, a := range arguments {
+		if a.Variadic && i != len(arguments)-1 {
+			panic(fmt.Sprintf("variadic argument must be the last one: %s", a.Name))
+		}
+		if a.Required && optional {
+			panic(fmt.Sprintf("required argument must be before optional arguments: %s", a.Name))
+		}
+		if !a.Required {
+			optional = true
+		}
+		a.initialize()
+	}
+}
+
+// bindArguments checks the arity of args and sets the values into arguments
+func bindArguments(arguments []*Argument, args []string) error {
+	if len(arguments) == 0 {
+		return nil // any arguments are accepted if not declared
+	}
+
+	var missing []string
+	for i, a := range arguments {
+		if i >= len(args) {
+			if a.Required {
+				missing = append(missing, a.Name)
+			}
+			continue
+		}
+
+		values := args[i : i+1]
+		if a.Variadic {
+			values = args[i:]
+		}
+		for _, value := range values {
+			if err := a.wrapValue.Set(value); err != nil {
+				return fmt.Errorf("invalid argument '%s' for %s: %v", value, a.Name, err)
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required arguments: %s", strings.Join(missing, ", "))
+	}
+
+	n := len(arguments)
+	if n > 0 && arguments[n-1].Variadic {
+		return nil
+	}
+	if len(args) > n {
+		return fmt.Errorf("too many arguments: %s", strings.Join(args[n:], " "))
+	}
+	return nil
+}
+
+func makeArgumentLabel(a *Argument) string {
+	label := a.Name
+	if a.Variadic {
+		label = label + "..."
+	}
+	if !a.Required {
+		label = "[" + label + "]"
+	}
+	return label
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestArgumentBind(t *testing.T) {
+	var src string
+	var n int
+	var files []string
+
+	arguments := []*Argument{
+		{Name: "SRC", Required: true, Value: &src},
+		{Name: "N", Value: &n},
+		{Name: "FILES", Variadic: true, Value: &files},
+	}
+	initializeArguments(arguments)
+
+	err := bindArguments(arguments, []string{"a", "2", "f1", "f2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src != "a" {
+		t.Error("SRC is wrong")
+	}
+	if n != 2 {
+		t.Error("N is wrong")
+	}
+	if !reflect.DeepEqual(files, []string{"f1", "f2"}) {
+		t.Errorf("FILES is wrong, got: %v", files)
+	}
+}
+
+func TestArgumentBindError(t *testing.T) {
+	arguments := []*Argument{
+		{Name: "SRC", Required: true},
+		{Name: "DEST", Required: true},
+		{Name: "N", Value: new(int)},
+	}
+	initializeArguments(arguments)
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{}, "missing required arguments: SRC, DEST"},
+		{[]string{"a", "b", "x"}, `invalid argument 'x' for N: strconv.ParseInt: parsing "x": invalid syntax`},
+		{[]string{"a", "b", "1", "c", "d"}, "too many arguments: c d"},
+	}
+
+	for _, tt := range tests {
+		err := bindArguments(arguments, tt.args)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestArgumentBindUndeclared(t *testing.T) {
+	if err := bindArguments(nil, []string{"a", "b"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestArgumentInitializePanic(t *testing.T) {
+	defer func() {
+		if e := recover(); e == nil {
+			t.Fatal("no panic found")
+		}
+	}()
+
+	initializeArguments([]*Argument{
+		{Name: "FILES", Variadic: true},
+		{Name: "DEST"},
+	})
+}
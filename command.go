@@ -1,7 +1,6 @@
 package cli
 
 import (
-	"fmt"
 	"strings"
 )
 
@@ -24,6 +23,10 @@ type Command struct {
 	Flags []*Flag
 	// List of commands to execute
 	Commands []*Command
+	// List of positional arguments, only used when no subcommands are specified
+	Arguments []*Argument
+	// Constraints of flags, e.g. mutually exclusive flags
+	FlagGroups []*FlagGroup
 
 	// hidden --help from usage
 	HiddenHelp bool
@@ -37,42 +40,70 @@ type Command struct {
 	// Display full help
 	ShowHelp func(*HelpContext)
 
+	// Executed before any subcommands or action, the execution is aborted if it returns an error.
+	// If the action runs, its flags and arguments are validated before Before,
+	// but the flags of subcommands are not parsed yet.
+	Before func(*Context) error
+	// Executed after any subcommands or action, even if they or Before return an error or panic
+	After func(*Context) error
+
 	// The action to execute when no subcommands are specified
 	Action func(*Context)
+	// Same as Action, but returns an error. It is used in preference to Action if set
+	ActionE func(*Context) error
+
+	// Returns the candidates of positional arguments for shell completion,
+	// a candidate can be "value" or "value\tdescription"
+	Complete func(c *Context, toComplete string) []string
 
 	// Execute this function if the proper command cannot be found
 	OnCommandNotFound func(*Context, string)
+
+	middlewares []Middleware // see Use()
 }
 
 func (c *Command) initialize() {
-	// add --help
-	c.Flags = append(c.Flags, &Flag{
-		Name:   "help",
-		Usage:  "print this usage",
-		IsBool: true,
-		Hidden: c.HiddenHelp,
-	})
+	// add --help, only once if run multiple times
+	if lookupFlag(c.Flags, "help") == nil {
+		c.Flags = append(c.Flags, &Flag{
+			Name:   "help",
+			Usage:  "print this usage",
+			IsBool: true,
+			Hidden: c.HiddenHelp,
+		})
+	}
 
 	// initialize flags
 	for _, f := range c.Flags {
 		f.initialize()
 	}
+
+	initializeArguments(c.Arguments)
 }
 
-// Run is the entry point to the command, parse argument and call Execute() or subcommand.Execute()
+// Run is the entry point to the command, parse argument and call Execute() or subcommand.Execute().
+// It shows the error and exits if failed.
 func (c *Command) Run(ctx *Context) {
+	if err := c.RunE(ctx); err != nil {
+		ctx.handleError(err)
+	}
+}
+
+// RunE is like Run, but returns the error instead of calling os.Exit
+func (c *Command) RunE(ctx *Context) (err error) {
 	c.initialize()
 
 	if c.ShowHelp == nil {
 		c.ShowHelp = showHelp
 	}
 
+	// own flags and persistent flags inherited from parent
+	flags := make([]*Flag, 0, len(c.Flags))
+	flags = append(flags, c.Flags...)
+	flags = append(flags, ctx.persistentFlags()...)
+
 	// parse cli arguments
-	cl := &commandline{
-		flags:    c.Flags,
-		commands: c.Commands,
-	}
-	var err error
+	cl := ctx.app.newCommandline(flags, c.Commands)
 	if c.SkipFlagParsing {
 		cl.args = ctx.args[1:]
 	} else {
@@ -81,22 +112,30 @@ func (c *Command) Run(ctx *Context) {
 
 	// build context
 	newCtx := &Context{
-		name:     ctx.name + " " + c.Name,
-		app:      ctx.app,
-		command:  c,
-		flags:    c.Flags,
-		commands: c.Commands,
-		args:     cl.args,
-		parent:   ctx,
+		ctx:       ctx.ctx,
+		name:      ctx.name + " " + c.Name,
+		app:       ctx.app,
+		command:   c,
+		flags:     flags,
+		commands:  c.Commands,
+		arguments: c.Arguments,
+		args:      cl.args,
+		parent:    ctx,
 	}
 
 	if err != nil {
-		newCtx.ShowError(err)
+		return &UsageError{Name: newCtx.name, Err: err}
 	}
 
 	// show --help
 	if newCtx.GetBool("help") {
-		newCtx.ShowHelpAndExit(0)
+		newCtx.ShowHelp()
+		return nil
+	}
+
+	// load flags from config file
+	if err := newCtx.applyValueSources(); err != nil {
+		return err
 	}
 
 	// command not found
@@ -104,24 +143,46 @@ func (c *Command) Run(ctx *Context) {
 		cmd := cl.args[0]
 		if c.OnCommandNotFound != nil {
 			c.OnCommandNotFound(newCtx, cmd)
-		} else {
-			newCtx.ShowError(fmt.Errorf("no such command: %s", cmd))
+			return nil
+		}
+		return &UsageError{Name: newCtx.name, Err: &CommandNotFoundError{
+			Name:        cmd,
+			Suggestions: ctx.app.suggestCommands(cmd, c.Commands),
+		}}
+	}
+
+	// validate flags and arguments of action before hooks
+	action := actionFunc(c.Action, c.ActionE)
+	if cl.command == nil && action != nil {
+		newCtx.debugFlags()
+		if err := newCtx.checkRequiredFlags(); err != nil {
+			return err
+		}
+		if err := newCtx.checkFlagGroups(); err != nil {
+			return err
+		}
+		if err := bindArguments(newCtx.arguments, newCtx.args); err != nil {
+			return &UsageError{Name: newCtx.name, Err: err}
 		}
-		return
+	}
+
+	// run hooks around subcommands and action, After is executed even if Before fails
+	defer newCtx.runAfter(c.After, &err)
+	if err := newCtx.runBefore(c.Before); err != nil {
+		return err
 	}
 
 	// run command
 	if cl.command != nil {
-		cl.command.Run(newCtx)
-		return
+		return cl.command.RunE(newCtx)
 	}
 
-	if c.Action != nil {
-		defer newCtx.handlePanic()
-		c.Action(newCtx)
-	} else {
-		newCtx.ShowHelpAndExit(0)
+	if action != nil {
+		return newCtx.runAction(action)
 	}
+
+	newCtx.ShowHelp()
+	return nil
 }
 
 // Names returns the names including short names and aliases
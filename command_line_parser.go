@@ -10,6 +10,11 @@ type commandline struct {
 	flags    []*Flag
 	commands []*Command
 
+	// options
+	allowAbbrev     bool // allow unambiguous prefix of long flag name
+	suggestDistance int  // max edit distance for suggestions, 0 is disabled
+	suggestHidden   bool // include hidden flags in suggestions
+
 	// parsed results
 	command *Command
 	args    []string
@@ -68,6 +73,8 @@ func (c *commandline) parseOneArg(i int, arguments []string) (bool, error) {
 		if len(arg) > 2 {
 			if arg[2] == '=' {
 				valueInline = arg[3:] // -x=value
+			} else if f := lookupFlag(c.flags, name); f != nil && (f.IsBool || f.Count) {
+				return c.parseShortFlags(i, arguments) // -abc
 			} else {
 				valueInline = arg[2:] // -xvalue
 			}
@@ -89,8 +96,38 @@ func (c *commandline) parseOneArg(i int, arguments []string) (bool, error) {
 	}
 
 	flag := lookupFlag(c.flags, name)
+	if flag == nil && prefix == "--" && c.allowAbbrev {
+		f, err := c.lookupFlagByPrefix(name)
+		if err != nil {
+			return false, err
+		}
+		flag = f
+	}
+	if flag == nil && prefix == "--" && strings.HasPrefix(name, "no-") {
+		// --no-name
+		if f := lookupFlag(c.flags, name[3:]); f != nil && f.IsBool && f.Negatable {
+			if valueInline != "" {
+				return false, fmt.Errorf("option '%s' doesn't allow an argument", prefix+name)
+			}
+			err := f.SetValue("false")
+			return false, err
+		}
+	}
 	if flag == nil {
-		return false, fmt.Errorf("unrecognized option '%s'", prefix+name)
+		err := &UnknownFlagError{Name: prefix + name}
+		if prefix == "--" {
+			err.Suggestions = c.suggestFlags(name)
+		}
+		return false, err
+	}
+
+	if flag.Count {
+		if valueInline == "" {
+			flag.increase()
+			return false, nil
+		}
+		err := flag.SetValue(valueInline)
+		return false, err
 	}
 
 	if flag.IsBool {
@@ -116,3 +153,104 @@ func (c *commandline) parseOneArg(i int, arguments []string) (bool, error) {
 
 	return valueNext != "", nil
 }
+
+// parseShortFlags parses combined short flags like "-abc",
+// the last flag may take a value from next argument like "-xvf file"
+func (c *commandline) parseShortFlags(i int, arguments []string) (bool, error) {
+	arg := arguments[i]
+	for j := 1; j < len(arg); j++ {
+		name := arg[j : j+1]
+		flag := lookupFlag(c.flags, name)
+		if flag == nil {
+			return false, &UnknownFlagError{Name: "-" + name}
+		}
+
+		if flag.Count {
+			flag.increase()
+			continue
+		}
+
+		if flag.IsBool {
+			if err := flag.SetValue("true"); err != nil {
+				return false, err
+			}
+			continue
+		}
+
+		if j < len(arg)-1 {
+			return false, fmt.Errorf("option '-%s' requires an argument, it must be the last one in '%s'", name, arg)
+		}
+
+		valueNext := ""
+		if i+1 < len(arguments) {
+			next := arguments[i+1]
+			if !strings.HasPrefix(next, "-") {
+				valueNext = next
+			}
+		}
+
+		value := valueNext
+		if value == "" {
+			value = flag.NoOptDefValue
+		}
+		if value == "" {
+			return false, fmt.Errorf("option requires an argument '-%s'", name)
+		}
+
+		err := flag.SetValue(value)
+		if err != nil {
+			return false, err
+		}
+		return valueNext != "", nil
+	}
+	return false, nil
+}
+
+// lookupFlagByPrefix finds the flag whose long name starts with the prefix,
+// returns an error if more than one flags are matched
+func (c *commandline) lookupFlagByPrefix(prefix string) (*Flag, error) {
+	var matched []*Flag
+	var matchedNames []string
+	for _, f := range c.flags {
+		for _, name := range f.Names() {
+			if len(name) > 1 && strings.HasPrefix(name, prefix) {
+				matched = append(matched, f)
+				matchedNames = append(matchedNames, "--"+name)
+				break
+			}
+		}
+	}
+
+	if len(matched) > 1 {
+		return nil, fmt.Errorf("ambiguous option '--%s' could match %s", prefix, strings.Join(matchedNames, ", "))
+	}
+	if len(matched) == 1 {
+		return matched[0], nil
+	}
+	return nil, nil
+}
+
+// suggestFlags returns the long flag names which are similar to the name
+func (c *commandline) suggestFlags(name string) []string {
+	if c.suggestDistance <= 0 {
+		return nil
+	}
+
+	var candidates []string
+	for _, f := range c.flags {
+		if f.Hidden && !c.suggestHidden {
+			continue
+		}
+		for _, n := range f.Names() {
+			if len(n) > 1 {
+				candidates = append(candidates, n)
+			}
+		}
+	}
+
+	suggestions := suggest(name, candidates, c.suggestDistance)
+	for i, s := range suggestions {
+		suggestions[i] = "--" + s
+	}
+	return suggestions
+}
@@ -179,3 +179,193 @@ func TestCommandlineParseCommandNotFound(t *testing.T) {
 		t.Fatal("command should be not found")
 	}
 }
+
+func TestCommandlineParseShortFlags(t *testing.T) {
+	var x, v bool
+	var f string
+
+	cl := &commandline{
+		flags: []*Flag{
+			{Name: "x", Value: &x},
+			{Name: "v", Value: &v},
+			{Name: "f", Value: &f},
+		},
+	}
+
+	// initialize flags
+	for _, f := range cl.flags {
+		f.initialize()
+	}
+
+	args := []string{"-xvf", "out.tar", "arg"}
+
+	err := cl.parse(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if x != true || v != true {
+		t.Error("wrong: -xv")
+	}
+	if f != "out.tar" {
+		t.Error("wrong: -f value")
+	}
+	if len(cl.args) != 1 || cl.args[0] != "arg" {
+		t.Errorf("wrong args: %v", cl.args)
+	}
+
+	// -f must be the last one
+	for _, arg := range []string{"-xfv", "-xfout.tar", "-xf=out.tar"} {
+		err = cl.parse([]string{arg, "out.tar"})
+		if err == nil {
+			t.Fatalf("%s: no error found", arg)
+		}
+		if err.Error() != `option '-f' requires an argument, it must be the last one in '`+arg+`'` {
+			t.Fatalf("%s: unexpected error: %v", arg, err)
+		}
+	}
+
+	// not a cluster if the first one takes a value
+	cl.args = nil
+	err = cl.parse([]string{"-fxv"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f != "xv" || len(cl.args) != 0 {
+		t.Errorf("wrong -fxv: %q, %v", f, cl.args)
+	}
+
+	// unknown flag in cluster
+	err = cl.parse([]string{"-xz"})
+	if err == nil || err.Error() != `unrecognized option '-z'` {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCommandlineParseNegatableFlag(t *testing.T) {
+	var color, cache bool
+
+	cl := &commandline{
+		flags: []*Flag{
+			{Name: "c, color", Value: &color, DefValue: "true", Negatable: true},
+			{Name: "cache", Value: &cache, DefValue: "true"},
+		},
+	}
+
+	// initialize flags
+	for _, f := range cl.flags {
+		f.initialize()
+	}
+
+	err := cl.parse([]string{"--no-color"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if color != false {
+		t.Error("wrong: --no-color")
+	}
+	if cl.flags[0].source.Kind != SourceCommandLine {
+		t.Error("color is not visited")
+	}
+
+	err = cl.parse([]string{"--no-color=true"})
+	if err == nil || err.Error() != `option '--no-color' doesn't allow an argument` {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// not negatable
+	err = cl.parse([]string{"--no-cache"})
+	if err == nil || err.Error() != `unrecognized option '--no-cache'` {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCommandlineParseCountFlag(t *testing.T) {
+	var verbose int
+	var x bool
+
+	cl := &commandline{
+		flags: []*Flag{
+			{Name: "v, verbose", Value: &verbose, Count: true},
+			{Name: "x", Value: &x},
+			{Name: "q, quiet", Count: true},
+		},
+	}
+
+	// initialize flags
+	for _, f := range cl.flags {
+		f.initialize()
+	}
+
+	err := cl.parse([]string{"-vvv", "--verbose", "-xv", "arg"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if verbose != 5 {
+		t.Errorf("wrong count: %d", verbose)
+	}
+	if x != true {
+		t.Error("wrong: -x")
+	}
+	if len(cl.args) != 1 {
+		t.Errorf("wrong args: %v", cl.args)
+	}
+
+	ctx := &Context{flags: cl.flags}
+	if ctx.GetInt("verbose") != 5 {
+		t.Error("verbose GetInt is wrong")
+	}
+	if ctx.GetInt("quiet") != 0 || ctx.IsSet("quiet") {
+		t.Error("quiet is wrong")
+	}
+
+	err = cl.parse([]string{"--verbose=1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verbose != 1 {
+		t.Errorf("wrong count: %d", verbose)
+	}
+}
+
+func TestCommandlineParseAbbrevFlag(t *testing.T) {
+	var verbose, version bool
+	var output string
+
+	cl := &commandline{
+		flags: []*Flag{
+			{Name: "verbose", Value: &verbose},
+			{Name: "version", Value: &version},
+			{Name: "o, output, output-dir", Value: &output},
+		},
+		allowAbbrev: true,
+	}
+
+	// initialize flags
+	for _, f := range cl.flags {
+		f.initialize()
+	}
+
+	err := cl.parse([]string{"--verb", "--out", "dir"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verbose != true || version != false {
+		t.Error("wrong: --verb")
+	}
+	if output != "dir" {
+		t.Error("wrong: --out")
+	}
+
+	err = cl.parse([]string{"--ver"})
+	if err == nil || err.Error() != `ambiguous option '--ver' could match --verbose, --version` {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cl.allowAbbrev = false
+	err = cl.parse([]string{"--verb"})
+	if err == nil || err.Error() != `unrecognized option '--verb'` {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
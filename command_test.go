@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -68,3 +69,21 @@ func TestCommandRunSubCmdNotFound(t *testing.T) {
 		t.Fatal("OnCommandNotFound not hit")
 	}
 }
+
+func TestCommandRunE(t *testing.T) {
+	ctx := &Context{
+		name: "app",
+		args: []string{"cmd", "--xxx"},
+	}
+
+	c := &Command{
+		Name:   "cmd",
+		Action: func(ctx *Context) {},
+	}
+
+	var usageErr *UsageError
+	err := c.RunE(ctx)
+	if !errors.As(err, &usageErr) || usageErr.Name != "app cmd" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
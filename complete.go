@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// CompletionDirective is a bit map to tell the shell how to handle the completion candidates
+type CompletionDirective int
+
+const (
+	// CompletionDefault lets the shell complete file names if no candidates
+	CompletionDefault CompletionDirective = 0
+	// CompletionNoFileComp prevents the shell from completing file names
+	CompletionNoFileComp CompletionDirective = 1 << 0
+	// CompletionNoSpace prevents the shell from adding a space after the completion
+	CompletionNoSpace CompletionDirective = 1 << 1
+)
+
+// completeCommandName is the hidden command invoked by completion scripts,
+// e.g. "app __complete build --target x86"
+const completeCommandName = "__complete"
+
+// SetCompletionDirective sets the directive in Flag.Complete() and Command.Complete()
+func (c *Context) SetCompletionDirective(directive CompletionDirective) {
+	c.completionDirective = directive
+}
+
+// runComplete prints the candidates for the last word of arguments,
+// one candidate per line as "value" or "value\tdescription",
+// and the last line is the directive as ":<directive>".
+func (a *App) runComplete(goctx context.Context, arguments []string) error {
+	toComplete := ""
+	if len(arguments) > 0 {
+		toComplete = arguments[len(arguments)-1]
+		arguments = arguments[:len(arguments)-1]
+	}
+	if toComplete == `""` {
+		toComplete = "" // empty argument passed by PowerShell
+	}
+
+	ctx, complete := a.completeContext(goctx, arguments)
+	ctx.completionDirective = CompletionDefault
+
+	candidates := ctx.completeCandidates(arguments, toComplete, complete)
+
+	w := ctx.Stdout()
+	for _, c := range candidates {
+		fmt.Fprintln(w, c)
+	}
+	fmt.Fprintf(w, ":%d\n", ctx.completionDirective)
+	return nil
+}
+
+// completeContext parses the arguments and returns the context of the last command,
+// and the completion callback for positional arguments.
+func (a *App) completeContext(goctx context.Context, arguments []string) (*Context, func(*Context, string) []string) {
+	// errors are ignored for the partial command line
+	cl := a.newCommandline(a.Flags, a.Commands)
+	cl.parse(arguments)
+
+	ctx := &Context{
+		ctx:       goctx,
+		name:      a.Name,
+		app:       a,
+		flags:     a.Flags,
+		commands:  a.Commands,
+		arguments: a.Arguments,
+		args:      cl.args,
+	}
+	complete := a.Complete
+
+	for cl.command != nil {
+		c := cl.command
+		c.initialize()
+
+		flags := make([]*Flag, 0, len(c.Flags))
+		flags = append(flags, c.Flags...)
+		flags = append(flags, ctx.persistentFlags()...)
+
+		parentArgs := ctx.args
+		cl = a.newCommandline(flags, c.Commands)
+		if c.SkipFlagParsing {
+			cl.args = parentArgs[1:]
+		} else {
+			cl.parse(parentArgs[1:])
+		}
+
+		ctx = &Context{
+			ctx:       goctx,
+			name:      ctx.name + " " + c.Name,
+			app:       a,
+			command:   c,
+			flags:     flags,
+			commands:  c.Commands,
+			arguments: c.Arguments,
+			args:      cl.args,
+			parent:    ctx,
+		}
+		complete = c.Complete
+	}
+
+	return ctx, complete
+}
+
+func (c *Context) completeCandidates(arguments []string, toComplete string, complete func(*Context, string) []string) []string {
+	// --name=value
+	if strings.HasPrefix(toComplete, "-") && strings.Contains(toComplete, "=") {
+		kv := strings.SplitN(toComplete, "=", 2)
+		f := lookupFlag(c.flags, strings.TrimLeft(kv[0], "-"))
+		if f == nil || !f.hasCompletion() {
+			return nil
+		}
+		c.completionDirective = CompletionNoFileComp
+		candidates := filterCandidates(f.completeValue(c, kv[1]), kv[1])
+		for i, candidate := range candidates {
+			candidates[i] = kv[0] + "=" + candidate
+		}
+		return candidates
+	}
+
+	// --name value
+	if n := len(arguments); n > 0 && strings.HasPrefix(arguments[n-1], "-") && !strings.Contains(arguments[n-1], "=") {
+		name := strings.TrimLeft(arguments[n-1], "-")
+		if !strings.HasPrefix(arguments[n-1], "--") {
+			name = name[len(name)-1:] // last one in combined short flags
+		}
+		if f := lookupFlag(c.flags, name); f != nil && f.takesValue() {
+			if !f.hasCompletion() {
+				return nil
+			}
+			c.completionDirective = CompletionNoFileComp
+			return filterCandidates(f.completeValue(c, toComplete), toComplete)
+		}
+	}
+
+	// flags
+	if strings.HasPrefix(toComplete, "-") {
+		c.completionDirective = CompletionNoFileComp
+		var candidates []string
+		for _, f := range c.flags {
+			if f.Hidden {
+				continue
+			}
+			for _, name := range completionFlagNames(f) {
+				candidates = append(candidates, completionCandidate(name, f.Usage))
+			}
+		}
+		return filterCandidates(candidates, toComplete)
+	}
+
+	// commands
+	if len(c.commands) > 0 && len(c.args) == 0 {
+		c.completionDirective = CompletionNoFileComp
+		var candidates []string
+		for _, cmd := range c.commands {
+			if cmd.Hidden {
+				continue
+			}
+			for _, name := range cmd.Names() {
+				candidates = append(candidates, completionCandidate(name, cmd.Usage))
+			}
+		}
+		return filterCandidates(candidates, toComplete)
+	}
+
+	// positional arguments
+	if complete != nil {
+		c.completionDirective = CompletionNoFileComp
+		return filterCandidates(complete(c, toComplete), toComplete)
+	}
+	return nil
+}
+
+func completionCandidate(value, description string) string {
+	if description == "" {
+		return value
+	}
+	return value + "\t" + description
+}
+
+// filterCandidates returns the candidates which has the prefix
+func filterCandidates(candidates []string, prefix string) []string {
+	result := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) {
+			result = append(result, c)
+		}
+	}
+	return result
+}
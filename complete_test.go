@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAppRunComplete(t *testing.T) {
+	app := &App{
+		Name: "app",
+		Flags: []*Flag{
+			{Name: "d, debug", IsBool: true, Persistent: true},
+			{Name: "secret", Hidden: true},
+		},
+		Commands: []*Command{
+			{
+				Name:  "build, b",
+				Usage: "build project",
+				Flags: []*Flag{
+					{
+						Name: "t, target",
+						Complete: func(c *Context, toComplete string) []string {
+							return []string{"x86\tIntel", "arm"}
+						},
+					},
+					{Name: "o, output"},
+				},
+				Complete: func(c *Context, toComplete string) []string {
+					c.SetCompletionDirective(CompletionNoSpace)
+					return []string{"main.go", "main_test.go", "util.go"}
+				},
+			},
+			{Name: "internal", Hidden: true},
+		},
+	}
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{""}, "build\tbuild project\nb\tbuild project\n:1\n"},
+		{[]string{"--"}, "--debug\n--help\tprint this usage\n--version\tprint version information\n:1\n"},
+		{[]string{"build", "--target", ""}, "x86\tIntel\narm\n:1\n"},
+		{[]string{"-d", "b", "-t", "a"}, "arm\n:1\n"},
+		{[]string{"build", "--target=x"}, "--target=x86\tIntel\n:1\n"},
+		{[]string{"build", "--output", ""}, ":0\n"},
+		{[]string{"build", "main"}, "main.go\nmain_test.go\n:2\n"},
+		{[]string{"xxx", ""}, ":0\n"},
+	}
+
+	for _, tt := range tests {
+		buf := new(bytes.Buffer)
+		app.Stdout = buf
+
+		args := append([]string{"app", "__complete"}, tt.args...)
+		if err := app.RunE(args); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("complete %q, got: %q, want: %q", tt.args, got, tt.want)
+		}
+	}
+}
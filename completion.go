@@ -0,0 +1,599 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// completionNode is a command in the tree for completion scripts
+type completionNode struct {
+	id       string // identifier of the command path, e.g. app_build
+	names    []string
+	usage    string
+	flags    []*Flag // visible flags, including inherited persistent flags
+	commands []*completionNode
+	complete bool // positional arguments are completed by __complete
+}
+
+var completionIDReplacer = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+func newCompletionTree(a *App) *completionNode {
+	flags := completionFlags(a.Flags, nil)
+	if !a.HiddenHelp && lookupFlag(a.Flags, "help") == nil {
+		flags = append(flags, &Flag{Name: "help", Usage: "print this usage", IsBool: true})
+	}
+	if !a.HiddenVersion && lookupFlag(a.Flags, "version") == nil {
+		flags = append(flags, &Flag{Name: "version", Usage: "print version information", IsBool: true})
+	}
+
+	node := &completionNode{
+		id:       completionIDReplacer.ReplaceAllString(a.Name, "_"),
+		names:    []string{a.Name},
+		usage:    a.Usage,
+		flags:    flags,
+		complete: a.Complete != nil,
+	}
+	node.commands = newCompletionNodes(node, a.Commands, a.Flags)
+	return node
+}
+
+func newCompletionNodes(parent *completionNode, commands []*Command, parentFlags []*Flag) []*completionNode {
+	var persistentFlags []*Flag
+	for _, f := range parentFlags {
+		if f.Persistent {
+			persistentFlags = append(persistentFlags, f)
+		}
+	}
+
+	var nodes []*completionNode
+	for _, c := range commands {
+		if c.Hidden {
+			continue
+		}
+
+		flags := completionFlags(c.Flags, persistentFlags)
+		if !c.HiddenHelp && lookupFlag(c.Flags, "help") == nil {
+			flags = append(flags, &Flag{Name: "help", Usage: "print this usage", IsBool: true})
+		}
+
+		names := c.Names()
+		node := &completionNode{
+			id:       parent.id + "_" + completionIDReplacer.ReplaceAllString(names[0], "_"),
+			names:    names,
+			usage:    c.Usage,
+			flags:    flags,
+			complete: c.Complete != nil,
+		}
+		childFlags := make([]*Flag, 0, len(c.Flags)+len(persistentFlags))
+		childFlags = append(childFlags, c.Flags...)
+		childFlags = append(childFlags, persistentFlags...)
+		node.commands = newCompletionNodes(node, c.Commands, childFlags)
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// completionFlags returns visible flags and inherited flags which are not overridden
+func completionFlags(flags []*Flag, inheritedFlags []*Flag) []*Flag {
+	var result []*Flag
+	for _, f := range flags {
+		if !f.Hidden {
+			result = append(result, f)
+		}
+	}
+	for _, f := range inheritedFlags {
+		if f.Hidden || lookupFlag(flags, f.Names()[0]) != nil {
+			continue
+		}
+		result = append(result, f)
+	}
+	return result
+}
+
+// walk calls fn for the node and all descendant nodes
+func (n *completionNode) walk(fn func(*completionNode)) {
+	fn(n)
+	for _, c := range n.commands {
+		c.walk(fn)
+	}
+}
+
+// completionFlagNames returns the names with prefix, e.g. -o, --output, --no-color
+func completionFlagNames(f *Flag) []string {
+	var names []string
+	for _, name := range f.Names() {
+		if len(name) == 1 {
+			names = append(names, "-"+name)
+		} else {
+			names = append(names, "--"+name)
+			if f.Negatable && !f.takesValue() {
+				names = append(names, "--no-"+name)
+			}
+		}
+	}
+	return names
+}
+
+func newCompletionCommand(a *App) *Command {
+	return &Command{
+		Name:        "completion",
+		Usage:       "generate shell completion script",
+		Description: "Output shell completion script for bash, zsh, fish or powershell.",
+		Examples: fmt.Sprintf(`
+			source <(%[1]s completion bash)
+			%[1]s completion zsh > "${fpath[1]}/_%[1]s"
+			%[1]s completion fish > ~/.config/fish/completions/%[1]s.fish
+			%[1]s completion powershell | Out-String | Invoke-Expression
+		`, a.Name),
+		Arguments: []*Argument{
+			{Name: "SHELL", Usage: "bash, zsh, fish or powershell", Required: true},
+		},
+		ActionE: func(c *Context) error {
+			return a.GenCompletion(c.Stdout(), c.Arg(0))
+		},
+	}
+}
+
+// GenCompletion writes the completion script for the shell to w,
+// supported shells are bash, zsh, fish and powershell
+func (a *App) GenCompletion(w io.Writer, shell string) error {
+	switch shell {
+	case "bash":
+		return a.GenBashCompletion(w)
+	case "zsh":
+		return a.GenZshCompletion(w)
+	case "fish":
+		return a.GenFishCompletion(w)
+	case "powershell":
+		return a.GenPowerShellCompletion(w)
+	default:
+		return fmt.Errorf("unsupported shell: %s", shell)
+	}
+}
+
+// GenBashCompletion writes the bash completion script to w
+func (a *App) GenBashCompletion(w io.Writer) error {
+	tree := newCompletionTree(a)
+	buf := new(bytes.Buffer)
+
+	fmt.Fprintf(buf, "# bash completion for %s\n\n", a.Name)
+
+	// candidates from __complete
+	fmt.Fprintf(buf, "__%s_dynamic()\n{\n", tree.id)
+	fmt.Fprintf(buf, "    local IFS=$'\\n' lines line directive\n")
+	fmt.Fprintf(buf, "    lines=($(\"${COMP_WORDS[0]}\" %s \"${COMP_WORDS[@]:1:COMP_CWORD-1}\" \"${cur}\" 2>/dev/null))\n", completeCommandName)
+	fmt.Fprintf(buf, "    directive=\"${lines[${#lines[@]}-1]#:}\"\n")
+	fmt.Fprintf(buf, "    unset 'lines[${#lines[@]}-1]'\n\n")
+	fmt.Fprintf(buf, "    COMPREPLY=()\n")
+	fmt.Fprintf(buf, "    for line in \"${lines[@]}\"; do\n")
+	fmt.Fprintf(buf, "        COMPREPLY+=(\"${line%%%%$'\\t'*}\")\n")
+	fmt.Fprintf(buf, "    done\n")
+	fmt.Fprintf(buf, "    if (( ${directive:-0} & %d )); then\n", CompletionNoSpace)
+	fmt.Fprintf(buf, "        compopt -o nospace\n")
+	fmt.Fprintf(buf, "    fi\n")
+	fmt.Fprintf(buf, "    if [[ ${#COMPREPLY[@]} -eq 0 ]] && ! (( ${directive:-0} & %d )); then\n", CompletionNoFileComp)
+	fmt.Fprintf(buf, "        COMPREPLY=($(compgen -f -- \"${cur}\"))\n")
+	fmt.Fprintf(buf, "    fi\n")
+	fmt.Fprintf(buf, "}\n\n")
+
+	// vars of each command
+	fmt.Fprintf(buf, "__%s_vars()\n{\n", tree.id)
+	fmt.Fprintf(buf, "    case \"$1\" in\n")
+	tree.walk(func(n *completionNode) {
+		var commands, flags, valueFlags, dynamicFlags []string
+		for _, c := range n.commands {
+			commands = append(commands, c.names...)
+		}
+		for _, f := range n.flags {
+			names := completionFlagNames(f)
+			flags = append(flags, names...)
+			if f.takesValue() {
+				valueFlags = append(valueFlags, names...)
+				if f.hasCompletion() {
+					dynamicFlags = append(dynamicFlags, names...)
+				}
+			}
+		}
+		fmt.Fprintf(buf, "        %s)\n", n.id)
+		fmt.Fprintf(buf, "            commands=%s\n", bashQuote(strings.Join(commands, " ")))
+		fmt.Fprintf(buf, "            flags=%s\n", bashQuote(strings.Join(flags, " ")))
+		fmt.Fprintf(buf, "            valueflags=%s\n", bashQuote(strings.Join(valueFlags, " ")))
+		fmt.Fprintf(buf, "            dynamicflags=%s\n", bashQuote(strings.Join(dynamicFlags, " ")))
+		fmt.Fprintf(buf, "            dynamicargs=%d\n", boolToInt(n.complete))
+		fmt.Fprintf(buf, "            ;;\n")
+	})
+	fmt.Fprintf(buf, "    esac\n}\n\n")
+
+	// completion function
+	fmt.Fprintf(buf, "__%s_complete()\n{\n", tree.id)
+	fmt.Fprintf(buf, "    local cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	fmt.Fprintf(buf, "    local prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	fmt.Fprintf(buf, "    local cmd=%s commands flags valueflags dynamicflags dynamicargs i word\n\n", tree.id)
+	fmt.Fprintf(buf, "    __%s_vars \"${cmd}\"\n", tree.id)
+	fmt.Fprintf(buf, "    for ((i = 1; i < COMP_CWORD; i++)); do\n")
+	fmt.Fprintf(buf, "        word=\"${COMP_WORDS[i]}\"\n")
+	fmt.Fprintf(buf, "        if [[ \"${word}\" == -* ]]; then\n")
+	fmt.Fprintf(buf, "            if [[ \" ${valueflags} \" == *\" ${word} \"* ]]; then\n")
+	fmt.Fprintf(buf, "                ((i++))\n")
+	fmt.Fprintf(buf, "            fi\n")
+	fmt.Fprintf(buf, "            continue\n")
+	fmt.Fprintf(buf, "        fi\n")
+	fmt.Fprintf(buf, "        case \"${cmd}:${word}\" in\n")
+	tree.walk(func(n *completionNode) {
+		for _, c := range n.commands {
+			patterns := make([]string, 0, len(c.names))
+			for _, name := range c.names {
+				patterns = append(patterns, bashQuote(n.id+":"+name))
+			}
+			fmt.Fprintf(buf, "            %s)\n", strings.Join(patterns, "|"))
+			fmt.Fprintf(buf, "                cmd=%s\n", c.id)
+			fmt.Fprintf(buf, "                __%s_vars \"${cmd}\"\n", tree.id)
+			fmt.Fprintf(buf, "                ;;\n")
+		}
+	})
+	fmt.Fprintf(buf, "        esac\n")
+	fmt.Fprintf(buf, "    done\n\n")
+	fmt.Fprintf(buf, "    if [[ \" ${dynamicflags} \" == *\" ${prev} \"* ]]; then\n")
+	fmt.Fprintf(buf, "        __%s_dynamic\n", tree.id)
+	fmt.Fprintf(buf, "    elif [[ \" ${valueflags} \" == *\" ${prev} \"* ]]; then\n")
+	fmt.Fprintf(buf, "        COMPREPLY=($(compgen -f -- \"${cur}\"))\n")
+	fmt.Fprintf(buf, "    elif [[ \"${cur}\" == -* ]]; then\n")
+	fmt.Fprintf(buf, "        COMPREPLY=($(compgen -W \"${flags}\" -- \"${cur}\"))\n")
+	fmt.Fprintf(buf, "    elif [[ -n \"${commands}\" ]]; then\n")
+	fmt.Fprintf(buf, "        COMPREPLY=($(compgen -W \"${commands}\" -- \"${cur}\"))\n")
+	fmt.Fprintf(buf, "    elif [[ \"${dynamicargs}\" == 1 ]]; then\n")
+	fmt.Fprintf(buf, "        __%s_dynamic\n", tree.id)
+	fmt.Fprintf(buf, "    else\n")
+	fmt.Fprintf(buf, "        COMPREPLY=($(compgen -f -- \"${cur}\"))\n")
+	fmt.Fprintf(buf, "    fi\n")
+	fmt.Fprintf(buf, "}\n\n")
+
+	fmt.Fprintf(buf, "complete -o filenames -F __%s_complete %s\n", tree.id, bashQuote(a.Name))
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// GenZshCompletion writes the zsh completion script to w
+func (a *App) GenZshCompletion(w io.Writer) error {
+	tree := newCompletionTree(a)
+	buf := new(bytes.Buffer)
+
+	fmt.Fprintf(buf, "#compdef %s\n\n", a.Name)
+	fmt.Fprintf(buf, "# zsh completion for %s\n\n", a.Name)
+
+	// candidates from __complete
+	fmt.Fprintf(buf, "__%s_dynamic() {\n", tree.id)
+	fmt.Fprintf(buf, "    local -a lines candidates opts\n")
+	fmt.Fprintf(buf, "    local line directive\n")
+	fmt.Fprintf(buf, "    lines=(\"${(@f)$(${words[1]} %s \"${(@)words[2,CURRENT-1]}\" \"${words[CURRENT]}\" 2>/dev/null)}\")\n", completeCommandName)
+	fmt.Fprintf(buf, "    directive=\"${lines[-1]#:}\"\n")
+	fmt.Fprintf(buf, "    lines=(\"${(@)lines[1,-2]}\")\n\n")
+	fmt.Fprintf(buf, "    for line in \"${lines[@]}\"; do\n")
+	fmt.Fprintf(buf, "        if [[ \"${line}\" == *$'\\t'* ]]; then\n")
+	fmt.Fprintf(buf, "            candidates+=(\"${${line%%%%$'\\t'*}//:/\\\\:}:${line#*$'\\t'}\")\n")
+	fmt.Fprintf(buf, "        elif [[ -n \"${line}\" ]]; then\n")
+	fmt.Fprintf(buf, "            candidates+=(\"${line//:/\\\\:}\")\n")
+	fmt.Fprintf(buf, "        fi\n")
+	fmt.Fprintf(buf, "    done\n")
+	fmt.Fprintf(buf, "    if (( ${directive:-0} & %d )); then\n", CompletionNoSpace)
+	fmt.Fprintf(buf, "        opts=(-S '')\n")
+	fmt.Fprintf(buf, "    fi\n")
+	fmt.Fprintf(buf, "    if (( ${#candidates} )); then\n")
+	fmt.Fprintf(buf, "        _describe -t values 'value' candidates \"${opts[@]}\"\n")
+	fmt.Fprintf(buf, "    elif ! (( ${directive:-0} & %d )); then\n", CompletionNoFileComp)
+	fmt.Fprintf(buf, "        _files\n")
+	fmt.Fprintf(buf, "    fi\n")
+	fmt.Fprintf(buf, "}\n\n")
+
+	// vars of each command
+	fmt.Fprintf(buf, "__%s_vars() {\n", tree.id)
+	fmt.Fprintf(buf, "    case \"$1\" in\n")
+	tree.walk(func(n *completionNode) {
+		var commands, flags, valueFlags, dynamicFlags []string
+		for _, c := range n.commands {
+			for _, name := range c.names {
+				commands = append(commands, shellQuote(zshDescribeItem(name, c.usage)))
+			}
+		}
+		for _, f := range n.flags {
+			names := completionFlagNames(f)
+			for _, name := range names {
+				flags = append(flags, shellQuote(zshDescribeItem(name, f.Usage)))
+			}
+			if f.takesValue() {
+				for _, name := range names {
+					valueFlags = append(valueFlags, shellQuote(name))
+					if f.hasCompletion() {
+						dynamicFlags = append(dynamicFlags, shellQuote(name))
+					}
+				}
+			}
+		}
+		fmt.Fprintf(buf, "        %s)\n", n.id)
+		fmt.Fprintf(buf, "            commands=(%s)\n", strings.Join(commands, " "))
+		fmt.Fprintf(buf, "            flags=(%s)\n", strings.Join(flags, " "))
+		fmt.Fprintf(buf, "            valueflags=(%s)\n", strings.Join(valueFlags, " "))
+		fmt.Fprintf(buf, "            dynamicflags=(%s)\n", strings.Join(dynamicFlags, " "))
+		fmt.Fprintf(buf, "            dynamicargs=%d\n", boolToInt(n.complete))
+		fmt.Fprintf(buf, "            ;;\n")
+	})
+	fmt.Fprintf(buf, "    esac\n}\n\n")
+
+	// completion function
+	fmt.Fprintf(buf, "_%s() {\n", tree.id)
+	fmt.Fprintf(buf, "    local cmd=%s dynamicargs i word\n", tree.id)
+	fmt.Fprintf(buf, "    local -a commands flags valueflags dynamicflags\n\n")
+	fmt.Fprintf(buf, "    __%s_vars \"${cmd}\"\n", tree.id)
+	fmt.Fprintf(buf, "    for ((i = 2; i < CURRENT; i++)); do\n")
+	fmt.Fprintf(buf, "        word=\"${words[i]}\"\n")
+	fmt.Fprintf(buf, "        if [[ \"${word}\" == -* ]]; then\n")
+	fmt.Fprintf(buf, "            if (( ${valueflags[(Ie)${word}]} )); then\n")
+	fmt.Fprintf(buf, "                ((i++))\n")
+	fmt.Fprintf(buf, "            fi\n")
+	fmt.Fprintf(buf, "            continue\n")
+	fmt.Fprintf(buf, "        fi\n")
+	fmt.Fprintf(buf, "        case \"${cmd}:${word}\" in\n")
+	tree.walk(func(n *completionNode) {
+		for _, c := range n.commands {
+			patterns := make([]string, 0, len(c.names))
+			for _, name := range c.names {
+				patterns = append(patterns, shellQuote(n.id+":"+name))
+			}
+			fmt.Fprintf(buf, "            %s)\n", strings.Join(patterns, "|"))
+			fmt.Fprintf(buf, "                cmd=%s\n", c.id)
+			fmt.Fprintf(buf, "                __%s_vars \"${cmd}\"\n", tree.id)
+			fmt.Fprintf(buf, "                ;;\n")
+		}
+	})
+	fmt.Fprintf(buf, "        esac\n")
+	fmt.Fprintf(buf, "    done\n\n")
+	fmt.Fprintf(buf, "    if (( ${dynamicflags[(Ie)${words[CURRENT-1]}]} )); then\n")
+	fmt.Fprintf(buf, "        __%s_dynamic\n", tree.id)
+	fmt.Fprintf(buf, "    elif (( ${valueflags[(Ie)${words[CURRENT-1]}]} )); then\n")
+	fmt.Fprintf(buf, "        _files\n")
+	fmt.Fprintf(buf, "    elif [[ \"${words[CURRENT]}\" == -* ]]; then\n")
+	fmt.Fprintf(buf, "        _describe -t options 'option' flags\n")
+	fmt.Fprintf(buf, "    elif (( ${#commands} )); then\n")
+	fmt.Fprintf(buf, "        _describe -t commands 'command' commands\n")
+	fmt.Fprintf(buf, "    elif (( dynamicargs )); then\n")
+	fmt.Fprintf(buf, "        __%s_dynamic\n", tree.id)
+	fmt.Fprintf(buf, "    else\n")
+	fmt.Fprintf(buf, "        _files\n")
+	fmt.Fprintf(buf, "    fi\n")
+	fmt.Fprintf(buf, "}\n\n")
+
+	fmt.Fprintf(buf, "if [[ \"${funcstack[1]}\" == \"_%s\" ]]; then\n", tree.id)
+	fmt.Fprintf(buf, "    _%s \"$@\"\n", tree.id)
+	fmt.Fprintf(buf, "else\n")
+	fmt.Fprintf(buf, "    compdef _%s %s\n", tree.id, shellQuote(a.Name))
+	fmt.Fprintf(buf, "fi\n")
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// GenFishCompletion writes the fish completion script to w
+func (a *App) GenFishCompletion(w io.Writer) error {
+	tree := newCompletionTree(a)
+	buf := new(bytes.Buffer)
+
+	fmt.Fprintf(buf, "# fish completion for %s\n\n", a.Name)
+
+	// candidates from __complete
+	fmt.Fprintf(buf, "function __%s_dynamic\n", tree.id)
+	fmt.Fprintf(buf, "    set -l tokens (commandline -opc)\n")
+	fmt.Fprintf(buf, "    for line in (command $tokens[1] %s $tokens[2..-1] (commandline -ct) 2>/dev/null)\n", completeCommandName)
+	fmt.Fprintf(buf, "        string match -q -- ':*' $line; or echo $line\n")
+	fmt.Fprintf(buf, "    end\n")
+	fmt.Fprintf(buf, "end\n\n")
+
+	// returns the id of current command
+	fmt.Fprintf(buf, "function __%s_command\n", tree.id)
+	fmt.Fprintf(buf, "    set -l tokens (commandline -opc)\n")
+	fmt.Fprintf(buf, "    set -l cmd %s\n", tree.id)
+	fmt.Fprintf(buf, "    set -l skip 0\n")
+	fmt.Fprintf(buf, "    for token in $tokens[2..-1]\n")
+	fmt.Fprintf(buf, "        if test $skip -eq 1\n")
+	fmt.Fprintf(buf, "            set skip 0\n")
+	fmt.Fprintf(buf, "            continue\n")
+	fmt.Fprintf(buf, "        end\n")
+	fmt.Fprintf(buf, "        switch \"$cmd:$token\"\n")
+	tree.walk(func(n *completionNode) {
+		var valueFlags []string
+		for _, f := range n.flags {
+			if f.takesValue() {
+				for _, name := range completionFlagNames(f) {
+					valueFlags = append(valueFlags, shellQuote(n.id+":"+name))
+				}
+			}
+		}
+		if len(valueFlags) > 0 {
+			fmt.Fprintf(buf, "            case %s\n", strings.Join(valueFlags, " "))
+			fmt.Fprintf(buf, "                set skip 1\n")
+		}
+		for _, c := range n.commands {
+			patterns := make([]string, 0, len(c.names))
+			for _, name := range c.names {
+				patterns = append(patterns, shellQuote(n.id+":"+name))
+			}
+			fmt.Fprintf(buf, "            case %s\n", strings.Join(patterns, " "))
+			fmt.Fprintf(buf, "                set cmd %s\n", c.id)
+		}
+	})
+	fmt.Fprintf(buf, "        end\n")
+	fmt.Fprintf(buf, "    end\n")
+	fmt.Fprintf(buf, "    echo $cmd\n")
+	fmt.Fprintf(buf, "end\n\n")
+
+	dynamic := shellQuote(fmt.Sprintf("(__%s_dynamic)", tree.id))
+	tree.walk(func(n *completionNode) {
+		cond := shellQuote(fmt.Sprintf("test (__%s_command) = %s", tree.id, n.id))
+		for _, c := range n.commands {
+			for _, name := range c.names {
+				fmt.Fprintf(buf, "complete -c %s -n %s -f -a %s -d %s\n",
+					shellQuote(a.Name), cond, shellQuote(name), shellQuote(c.usage))
+			}
+		}
+		if n.complete && len(n.commands) == 0 {
+			fmt.Fprintf(buf, "complete -c %s -n %s -a %s\n", shellQuote(a.Name), cond, dynamic)
+		}
+		for _, f := range n.flags {
+			var opts []string
+			for _, name := range completionFlagNames(f) {
+				if strings.HasPrefix(name, "--") {
+					opts = append(opts, "-l "+shellQuote(name[2:]))
+				} else {
+					opts = append(opts, "-s "+shellQuote(name[1:]))
+				}
+			}
+			if f.takesValue() {
+				opts = append(opts, "-r")
+				if f.hasCompletion() {
+					opts = append(opts, "-f -a "+dynamic)
+				}
+			}
+			fmt.Fprintf(buf, "complete -c %s -n %s %s -d %s\n",
+				shellQuote(a.Name), cond, strings.Join(opts, " "), shellQuote(f.Usage))
+		}
+	})
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// GenPowerShellCompletion writes the PowerShell completion script to w
+func (a *App) GenPowerShellCompletion(w io.Writer) error {
+	tree := newCompletionTree(a)
+	buf := new(bytes.Buffer)
+
+	fmt.Fprintf(buf, "# powershell completion for %s\n\n", a.Name)
+
+	fmt.Fprintf(buf, "Register-ArgumentCompleter -Native -CommandName %s -ScriptBlock {\n", psQuote(a.Name))
+	fmt.Fprintf(buf, "    param($wordToComplete, $commandAst, $cursorPosition)\n\n")
+
+	// tree of commands
+	fmt.Fprintf(buf, "    $tree = @{\n")
+	tree.walk(func(n *completionNode) {
+		fmt.Fprintf(buf, "        %s = @{\n", psQuote(n.id))
+		fmt.Fprintf(buf, "            Commands = @(\n")
+		for _, c := range n.commands {
+			for _, name := range c.names {
+				fmt.Fprintf(buf, "                ,@(%s, %s, %s)\n", psQuote(name), psQuote(c.id), psQuote(c.usage))
+			}
+		}
+		fmt.Fprintf(buf, "            )\n")
+		fmt.Fprintf(buf, "            Flags = @(\n")
+		var valueFlags, dynamicFlags []string
+		for _, f := range n.flags {
+			names := completionFlagNames(f)
+			for _, name := range names {
+				fmt.Fprintf(buf, "                ,@(%s, %s)\n", psQuote(name), psQuote(f.Usage))
+			}
+			if f.takesValue() {
+				for _, name := range names {
+					valueFlags = append(valueFlags, psQuote(name))
+					if f.hasCompletion() {
+						dynamicFlags = append(dynamicFlags, psQuote(name))
+					}
+				}
+			}
+		}
+		fmt.Fprintf(buf, "            )\n")
+		fmt.Fprintf(buf, "            ValueFlags = @(%s)\n", strings.Join(valueFlags, ", "))
+		fmt.Fprintf(buf, "            DynamicFlags = @(%s)\n", strings.Join(dynamicFlags, ", "))
+		fmt.Fprintf(buf, "            DynamicArgs = $%t\n", n.complete)
+		fmt.Fprintf(buf, "        }\n")
+	})
+	fmt.Fprintf(buf, "    }\n\n")
+
+	fmt.Fprintf(buf, "    $words = @($commandAst.CommandElements | Select-Object -Skip 1 | ForEach-Object { $_.ToString() })\n")
+	fmt.Fprintf(buf, "    if ($wordToComplete -ne '') {\n")
+	fmt.Fprintf(buf, "        $words = @($words | Select-Object -SkipLast 1)\n")
+	fmt.Fprintf(buf, "    }\n\n")
+	fmt.Fprintf(buf, "    $cmd = %s\n", psQuote(tree.id))
+	fmt.Fprintf(buf, "    $skip = $false\n")
+	fmt.Fprintf(buf, "    $prev = ''\n")
+	fmt.Fprintf(buf, "    foreach ($word in $words) {\n")
+	fmt.Fprintf(buf, "        if ($skip) {\n")
+	fmt.Fprintf(buf, "            $skip = $false\n")
+	fmt.Fprintf(buf, "            continue\n")
+	fmt.Fprintf(buf, "        }\n")
+	fmt.Fprintf(buf, "        if ($word.StartsWith('-')) {\n")
+	fmt.Fprintf(buf, "            $skip = $tree[$cmd].ValueFlags -contains $word\n")
+	fmt.Fprintf(buf, "            $prev = $word\n")
+	fmt.Fprintf(buf, "            continue\n")
+	fmt.Fprintf(buf, "        }\n")
+	fmt.Fprintf(buf, "        foreach ($c in $tree[$cmd].Commands) {\n")
+	fmt.Fprintf(buf, "            if ($c[0] -eq $word) {\n")
+	fmt.Fprintf(buf, "                $cmd = $c[1]\n")
+	fmt.Fprintf(buf, "                break\n")
+	fmt.Fprintf(buf, "            }\n")
+	fmt.Fprintf(buf, "        }\n")
+	fmt.Fprintf(buf, "    }\n\n")
+	fmt.Fprintf(buf, "    # complete the value of flag by files\n")
+	fmt.Fprintf(buf, "    if ($skip -and -not ($tree[$cmd].DynamicFlags -contains $prev)) {\n")
+	fmt.Fprintf(buf, "        return\n")
+	fmt.Fprintf(buf, "    }\n\n")
+	fmt.Fprintf(buf, "    # candidates from %s\n", completeCommandName)
+	fmt.Fprintf(buf, "    if ($skip -or (-not $wordToComplete.StartsWith('-') -and $tree[$cmd].Commands.Count -eq 0 -and $tree[$cmd].DynamicArgs)) {\n")
+	fmt.Fprintf(buf, "        $arg = if ($wordToComplete -eq '') { '\"\"' } else { $wordToComplete }\n")
+	fmt.Fprintf(buf, "        $lines = @(& $commandAst.CommandElements[0].ToString() %s @words $arg 2>$null)\n", completeCommandName)
+	fmt.Fprintf(buf, "        $lines | Where-Object { $_ -and -not $_.StartsWith(':') } | ForEach-Object {\n")
+	fmt.Fprintf(buf, "            $item = $_ -split \"`t\", 2\n")
+	fmt.Fprintf(buf, "            $tooltip = if ($item.Count -gt 1) { $item[1] } else { $item[0] }\n")
+	fmt.Fprintf(buf, "            [System.Management.Automation.CompletionResult]::new($item[0], $item[0], 'ParameterValue', $tooltip)\n")
+	fmt.Fprintf(buf, "        }\n")
+	fmt.Fprintf(buf, "        return\n")
+	fmt.Fprintf(buf, "    }\n\n")
+	fmt.Fprintf(buf, "    if ($wordToComplete.StartsWith('-')) {\n")
+	fmt.Fprintf(buf, "        $items = $tree[$cmd].Flags\n")
+	fmt.Fprintf(buf, "        $type = 'ParameterName'\n")
+	fmt.Fprintf(buf, "    } else {\n")
+	fmt.Fprintf(buf, "        $items = $tree[$cmd].Commands\n")
+	fmt.Fprintf(buf, "        $type = 'ParameterValue'\n")
+	fmt.Fprintf(buf, "    }\n")
+	fmt.Fprintf(buf, "    $items | Where-Object { $_[0] -like \"$wordToComplete*\" } | ForEach-Object {\n")
+	fmt.Fprintf(buf, "        $tooltip = if ($_[-1]) { $_[-1] } else { $_[0] }\n")
+	fmt.Fprintf(buf, "        [System.Management.Automation.CompletionResult]::new($_[0], $_[0], $type, $tooltip)\n")
+	fmt.Fprintf(buf, "    }\n")
+	fmt.Fprintf(buf, "}\n")
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// bashQuote quotes the string by double quotes
+func bashQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`")
+	return `"` + r.Replace(s) + `"`
+}
+
+// shellQuote quotes the string by single quotes for zsh and fish
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// psQuote quotes the string by single quotes for PowerShell
+func psQuote(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+// zshDescribeItem returns the item for _describe, e.g. name:description
+func zshDescribeItem(name, usage string) string {
+	name = strings.Replace(name, ":", `\:`, -1)
+	if usage == "" {
+		return name
+	}
+	return name + ":" + usage
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
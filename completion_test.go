@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func newCompletionTestApp() *App {
+	return &App{
+		Name: "app",
+		Flags: []*Flag{
+			{Name: "d, debug", IsBool: true, Persistent: true},
+			{Name: "o, output", Usage: "output file"},
+			{Name: "secret", Hidden: true},
+		},
+		Commands: []*Command{
+			{
+				Name:  "build, b",
+				Usage: "build project",
+				Flags: []*Flag{
+					{Name: "t, target", Usage: "build target"},
+				},
+			},
+			{
+				Name:   "internal",
+				Hidden: true,
+			},
+		},
+	}
+}
+
+func TestGenCompletion(t *testing.T) {
+	tests := []struct {
+		shell string
+		want  []string
+	}{
+		{"bash", []string{
+			`commands="build b"`,
+			`flags="-d --debug -o --output --help --version"`,
+			`valueflags="-t --target"`,
+			`"app:build"|"app:b")`,
+			`complete -o filenames -F __app_complete "app"`,
+		}},
+		{"zsh", []string{
+			`#compdef app`,
+			`commands=('build:build project' 'b:build project')`,
+			`flags=('-t:build target' '--target:build target' '-d' '--debug' '--help:print this usage')`,
+			`'app:build'|'app:b')`,
+			`compdef _app 'app'`,
+		}},
+		{"fish", []string{
+			`case 'app:build' 'app:b'`,
+			`case 'app_build:-t' 'app_build:--target'`,
+			`complete -c 'app' -n 'test (__app_command) = app' -f -a 'build' -d 'build project'`,
+			`complete -c 'app' -n 'test (__app_command) = app_build' -s 't' -l 'target' -r -d 'build target'`,
+			`complete -c 'app' -n 'test (__app_command) = app_build' -s 'd' -l 'debug' -d ''`,
+		}},
+		{"powershell", []string{
+			`Register-ArgumentCompleter -Native -CommandName 'app' -ScriptBlock {`,
+			`,@('b', 'app_build', 'build project')`,
+			`,@('--output', 'output file')`,
+			`ValueFlags = @('-t', '--target')`,
+		}},
+	}
+
+	for _, tt := range tests {
+		buf := new(bytes.Buffer)
+		err := newCompletionTestApp().GenCompletion(buf, tt.shell)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		script := buf.String()
+		for _, want := range tt.want {
+			if !strings.Contains(script, want) {
+				t.Errorf("%s completion does not contain: %s", tt.shell, want)
+			}
+		}
+		if strings.Contains(script, "secret") || strings.Contains(script, "internal") {
+			t.Errorf("%s completion contains hidden flag or command", tt.shell)
+		}
+	}
+}
+
+func TestGenCompletionUnsupported(t *testing.T) {
+	err := newCompletionTestApp().GenCompletion(new(bytes.Buffer), "tcsh")
+	if err == nil || err.Error() != "unsupported shell: tcsh" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestAppRunCompletionCommand(t *testing.T) {
+	buf := new(bytes.Buffer)
+	app := newCompletionTestApp()
+	app.EnableCompletion = true
+	app.Stdout = buf
+
+	err := app.RunE([]string{"app", "completion", "bash"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "# bash completion for app") {
+		t.Errorf("unexpected output: %s", buf.String())
+	}
+}
+
+func TestGenCompletionDynamic(t *testing.T) {
+	app := newCompletionTestApp()
+	app.Commands[0].Flags[0].Complete = func(c *Context, toComplete string) []string {
+		return nil
+	}
+	app.Commands[0].Complete = func(c *Context, toComplete string) []string {
+		return nil
+	}
+
+	tests := []struct {
+		shell string
+		want  []string
+	}{
+		{"bash", []string{`dynamicflags="-t --target"`, `dynamicargs=1`, `"${COMP_WORDS[0]}" __complete`}},
+		{"zsh", []string{`dynamicflags=('-t' '--target')`, `dynamicargs=1`, `${words[1]} __complete`}},
+		{"fish", []string{`-s 't' -l 'target' -r -f -a '(__app_dynamic)'`, `-n 'test (__app_command) = app_build' -a '(__app_dynamic)'`}},
+		{"powershell", []string{`DynamicFlags = @('-t', '--target')`, `DynamicArgs = $true`, `__complete @words $arg`}},
+	}
+
+	for _, tt := range tests {
+		buf := new(bytes.Buffer)
+		err := app.GenCompletion(buf, tt.shell)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		script := buf.String()
+		for _, want := range tt.want {
+			if !strings.Contains(script, want) {
+				t.Errorf("%s completion does not contain: %s", tt.shell, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,453 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ValueSource is a source of flag values besides cli args and environ, e.g. config file.
+// The precedence is: cli args > environ > value sources > default value
+type ValueSource interface {
+	// Lookup returns the values of key, the key of flag is the flag name
+	// prefixed by the names of commands, e.g. "output" or "build.output"
+	Lookup(key string) ([]string, bool)
+
+	// String returns the description of source for messages, e.g. file path
+	String() string
+}
+
+// ConfigParser parses the content of config file into nested maps
+type ConfigParser func(data []byte) (map[string]interface{}, error)
+
+// ConfigParsers are the parsers of config file by file extension.
+// You can add a parser for other formats.
+var ConfigParsers = map[string]ConfigParser{
+	".json": parseJSONConfig,
+	".toml": parseTOMLConfig,
+	".yaml": parseYAMLConfig,
+	".yml":  parseYAMLConfig,
+	".ini":  parseINIConfig,
+}
+
+// NewConfigFileSource loads the config file as a ValueSource,
+// the format is determined by the file extension, see ConfigParsers
+func NewConfigFileSource(path string) (ValueSource, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	parser, ok := ConfigParsers[ext]
+	if !ok {
+		return nil, fmt.Errorf("unsupported config file format: %s", path)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := parser(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+	if err := checkConfigValue("", config); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+
+	return NewMapValueSource(path, config), nil
+}
+
+// NewMapValueSource returns a ValueSource from nested maps,
+// e.g. {"build": {"output": "a.out"}} provides the value of key "build.output"
+func NewMapValueSource(name string, m map[string]interface{}) ValueSource {
+	s := &mapValueSource{
+		name:   name,
+		values: make(map[string][]string),
+	}
+	s.flatten("", m)
+	return s
+}
+
+type mapValueSource struct {
+	name   string
+	values map[string][]string
+}
+
+func (s *mapValueSource) Lookup(key string) ([]string, bool) {
+	values, ok := s.values[key]
+	return values, ok
+}
+
+func (s *mapValueSource) String() string {
+	return s.name
+}
+
+func (s *mapValueSource) flatten(prefix string, value interface{}) {
+	switch val := value.(type) {
+	case map[string]interface{}:
+		for k, v := range val {
+			if prefix != "" {
+				k = prefix + "." + k
+			}
+			s.flatten(k, v)
+		}
+	case []interface{}:
+		values := make([]string, 0, len(val))
+		for _, v := range val {
+			if v != nil {
+				values = append(values, configString(v))
+			}
+		}
+		s.values[prefix] = values
+	case nil:
+		// ignore null value
+	default:
+		s.values[prefix] = []string{configString(val)}
+	}
+}
+
+// checkConfigValue returns an error if a list contains a table or another list,
+// which can not be the values of flag
+func checkConfigValue(key string, value interface{}) error {
+	switch val := value.(type) {
+	case map[string]interface{}:
+		for k, v := range val {
+			if key != "" {
+				k = key + "." + k
+			}
+			if err := checkConfigValue(k, v); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, v := range val {
+			switch v.(type) {
+			case map[string]interface{}, []interface{}:
+				return fmt.Errorf("nested list or table in list is not supported: %s", key)
+			}
+		}
+	}
+	return nil
+}
+
+func configString(value interface{}) string {
+	switch val := value.(type) {
+	case string:
+		return val
+	case bool:
+		return strconv.FormatBool(val)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// loadValueSources loads the config file and the value sources of app
+func (a *App) loadValueSources(c *Context) ([]ValueSource, error) {
+	var sources []ValueSource
+
+	path := ""
+	if a.ConfigFlag != "" {
+		path = c.GetString(a.ConfigFlag) // may be set by cli args, environ or default value
+	}
+	if path == "" {
+		for _, p := range a.ConfigPaths {
+			p = expandHomeDir(p)
+			if _, err := os.Stat(p); err == nil {
+				path = p
+				break
+			}
+		}
+	}
+
+	if path != "" {
+		s, err := NewConfigFileSource(path)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, s)
+	}
+
+	return append(sources, a.ValueSources...), nil
+}
+
+func expandHomeDir(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
+
+// valueSources returns the value sources loaded by the root context
+func (c *Context) valueSources() []ValueSource {
+	return c.Global().sources
+}
+
+// applyValueSources sets the flags of context from value sources
+// if they are not set in cli args or environ
+func (c *Context) applyValueSources() error {
+	sources := c.valueSources()
+	if len(sources) == 0 {
+		return nil
+	}
+
+	flags := c.flags
+	if c.command != nil {
+		flags = c.command.Flags // persistent flags are applied by parent
+	}
+
+	prefix := c.configKeyPrefix()
+	for _, f := range flags {
+		if f.source.Kind > SourceConfig {
+			continue
+		}
+		if c.app != nil && c.app.ConfigFlag != "" && f == lookupFlag(flags, c.app.ConfigFlag) {
+			continue
+		}
+		if err := f.applyValueSources(sources, prefix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *Flag) applyValueSources(sources []ValueSource, prefix string) error {
+	for _, s := range sources {
+		for _, name := range f.Names() {
+			values, ok := s.Lookup(prefix + name)
+			if !ok {
+				continue
+			}
+			if f.source.Kind == SourceDefault {
+				f.resetSlice() // config file beats default value
+			}
+			for _, value := range values {
+				if err := f.wrapValue.Set(value); err != nil {
+					return fmt.Errorf("invalid value '%s' for option '%s' in %s: %v", value, prefix+name, s, err)
+				}
+			}
+			f.source = FlagSource{Kind: SourceConfig, Name: s.String(), Key: prefix + name}
+			return nil
+		}
+	}
+	return nil
+}
+
+// configKeyPrefix returns the names of commands joined by ".", e.g. "build.",
+// it is empty for app
+func (c *Context) configKeyPrefix() string {
+	var names []string
+	for ctx := c; ctx != nil; ctx = ctx.parent {
+		if ctx.command != nil {
+			names = append(names, ctx.command.Names()[0])
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+	return strings.Join(names, ".") + "."
+}
+
+// configTable returns the nested table of keys in config, creates it if not exists
+func configTable(config map[string]interface{}, keys []string) (map[string]interface{}, error) {
+	table := config
+	for _, key := range keys {
+		key = unquoteConfigKey(key)
+		switch val := table[key].(type) {
+		case nil:
+			sub := make(map[string]interface{})
+			table[key] = sub
+			table = sub
+		case map[string]interface{}:
+			table = val
+		default:
+			return nil, fmt.Errorf("key '%s' is not a table", key)
+		}
+	}
+	return table, nil
+}
+
+func unquoteConfigKey(key string) string {
+	key = strings.TrimSpace(key)
+	if len(key) >= 2 && (key[0] == '"' || key[0] == '\'') && key[len(key)-1] == key[0] {
+		return key[1 : len(key)-1]
+	}
+	return key
+}
+
+// stripConfigComment removes the comment which starts with one of markers
+// at the beginning or after a whitespace, and outside of quotes
+func stripConfigComment(s string, markers string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case quote != 0:
+			if ch == '\\' && quote == '"' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case strings.IndexByte(markers, ch) >= 0 && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return strings.TrimSpace(s[:i])
+		}
+	}
+	return strings.TrimSpace(s)
+}
+
+// splitConfigList splits the items by comma outside of quotes and brackets
+func splitConfigList(s string) []string {
+	var items []string
+	var quote byte
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case quote != 0:
+			if ch == '\\' && quote == '"' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '[' || ch == '{':
+			depth++
+		case ch == ']' || ch == '}':
+			depth--
+		case ch == ',' && depth == 0:
+			items = append(items, s[start:i])
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" {
+		items = append(items, last) // allow trailing comma
+	}
+	return items
+}
+
+// parseConfigScalar parses a quoted string, an inline list or a plain text for YAML and INI,
+// two single quotes in a single-quoted string are one quote as YAML does
+func parseConfigScalar(s string) (interface{}, error) {
+	s = strings.TrimSpace(s)
+	if len(s) == 0 {
+		return "", nil
+	}
+
+	switch s[0] {
+	case '"':
+		value, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid string: %s", s)
+		}
+		return value, nil
+	case '\'':
+		if len(s) < 2 || s[len(s)-1] != '\'' {
+			return nil, fmt.Errorf("invalid string: %s", s)
+		}
+		return strings.Replace(s[1:len(s)-1], "''", "'", -1), nil
+	case '[':
+		return parseConfigList(s, parseConfigScalar)
+	}
+	return s, nil
+}
+
+// parseConfigList parses an inline list like `[a, "b"]`, the items are parsed by parseItem
+func parseConfigList(s string, parseItem func(string) (interface{}, error)) ([]interface{}, error) {
+	if s[len(s)-1] != ']' {
+		return nil, fmt.Errorf("invalid list: %s", s)
+	}
+	items := splitConfigList(s[1 : len(s)-1])
+	list := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			return nil, fmt.Errorf("empty item in list: %s", s)
+		}
+		if item[0] == '[' || item[0] == '{' {
+			return nil, fmt.Errorf("nested list or table in list is not supported: %s", s)
+		}
+		value, err := parseItem(item)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+	}
+	return list, nil
+}
+
+// parseConfigInlineTable parses an inline table like `{ host = "x", port = 80 }`,
+// sep is the separator of key and value, "=" for TOML and ":" for YAML
+func parseConfigInlineTable(s string, sep string, parseValue func(string) (interface{}, error)) (map[string]interface{}, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[len(s)-1] != '}' {
+		return nil, fmt.Errorf("invalid inline table: %s", s)
+	}
+
+	table := make(map[string]interface{})
+	for _, item := range splitConfigList(s[1 : len(s)-1]) {
+		n := configInlineKey(item, sep)
+		if n < 0 {
+			return nil, fmt.Errorf("invalid key/value in inline table: %s", item)
+		}
+		keys := []string{item[:n]}
+		if sep == "=" {
+			keys = splitTOMLKey(item[:n])
+		}
+		rest := strings.TrimSpace(item[n+1:])
+
+		var value interface{}
+		var err error
+		if strings.HasPrefix(rest, "{") {
+			value, err = parseConfigInlineTable(rest, sep, parseValue)
+		} else {
+			value, err = parseValue(rest)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		parent, err := configTable(table, keys[:len(keys)-1])
+		if err != nil {
+			return nil, err
+		}
+		key := unquoteConfigKey(keys[len(keys)-1])
+		if _, ok := parent[key]; ok {
+			return nil, fmt.Errorf("duplicate key '%s'", key)
+		}
+		parent[key] = value
+	}
+	return table, nil
+}
+
+// configInlineKey returns the index of separator after key outside of quotes, or -1
+func configInlineKey(item string, sep string) int {
+	if sep == ":" {
+		return yamlMappingKey(item)
+	}
+	var quote byte
+	for i := 0; i < len(item); i++ {
+		ch := item[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == sep[0]:
+			return i
+		}
+	}
+	return -1
+}
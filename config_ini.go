@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseINIConfig parses the INI file, the keys in "[build]" section are
+// the options of command "build", and "[build.all]" is for "build all".
+// A repeated key is parsed as a list.
+func parseINIConfig(data []byte) (map[string]interface{}, error) {
+	config := make(map[string]interface{})
+	section := config
+
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: invalid section: %s", i+1, line)
+			}
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			var err error
+			section, err = configTable(config, strings.Split(name, "."))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", i+1, err)
+			}
+			continue
+		}
+
+		n := strings.IndexAny(line, "=:")
+		if n < 0 {
+			return nil, fmt.Errorf("line %d: invalid key/value: %s", i+1, line)
+		}
+		key := strings.TrimSpace(line[:n])
+		value, err := parseConfigScalar(stripConfigComment(line[n+1:], ";#"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+
+		switch old := section[key].(type) {
+		case nil:
+			section[key] = value
+		case []interface{}:
+			section[key] = append(old, value)
+		default:
+			section[key] = []interface{}{old, value}
+		}
+	}
+
+	return config, nil
+}
@@ -0,0 +1,16 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+func parseJSONConfig(data []byte) (map[string]interface{}, error) {
+	config := make(map[string]interface{})
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // keep the text of numbers
+	if err := decoder.Decode(&config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
@@ -0,0 +1,342 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConfigParsers(t *testing.T) {
+	cases := []struct {
+		ext  string
+		data string
+	}{
+		{".json", `{"name": "app", "port": 8080, "debug": true, "tags": ["a", "b"], "build": {"output": "a.out", "all": {"jobs": 4}}}`},
+		{".toml", `
+# comment
+name = "app"
+port = 8080
+debug = true
+tags = [
+  "a", # first
+  'b',
+]
+
+[build]
+output = "a.out" # inline comment
+all.jobs = 4
+`},
+		{".yaml", `
+---
+# comment
+name: app
+port: 8080
+debug: true
+tags:
+- a
+- 'b'
+build:
+  output: "a.out" # inline comment
+  all:
+    jobs: 4
+`},
+		{".ini", `
+; comment
+name = app
+port = 8080
+debug = true
+tags = a
+tags = "b"
+
+[build]
+output: a.out
+
+[build.all]
+jobs = 4
+`},
+	}
+
+	wants := map[string][]string{
+		"name":           {"app"},
+		"port":           {"8080"},
+		"debug":          {"true"},
+		"tags":           {"a", "b"},
+		"build.output":   {"a.out"},
+		"build.all.jobs": {"4"},
+	}
+
+	for _, tc := range cases {
+		config, err := ConfigParsers[tc.ext]([]byte(tc.data))
+		if err != nil {
+			t.Errorf("%s: %v", tc.ext, err)
+			continue
+		}
+		s := NewMapValueSource("test"+tc.ext, config)
+		for key, want := range wants {
+			if got, ok := s.Lookup(key); !ok || !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: Lookup(%q) = %q, want %q", tc.ext, key, got, want)
+			}
+		}
+	}
+}
+
+func TestConfigParsersInlineTable(t *testing.T) {
+	cases := []struct {
+		ext  string
+		data string
+	}{
+		{".toml", `db = { host = "localhost", "port" = 5432, pool.size = 4, opts = { tags = ["a", "b"] } }`},
+		{".yaml", `db: {host: localhost, "port": 5432, pool: {size: 4}, opts: {tags: [a, b]}}`},
+	}
+
+	wants := map[string][]string{
+		"db.host":      {"localhost"},
+		"db.port":      {"5432"},
+		"db.pool.size": {"4"},
+		"db.opts.tags": {"a", "b"},
+	}
+
+	for _, tc := range cases {
+		config, err := ConfigParsers[tc.ext]([]byte(tc.data))
+		if err != nil {
+			t.Errorf("%s: %v", tc.ext, err)
+			continue
+		}
+		s := NewMapValueSource("test"+tc.ext, config)
+		for key, want := range wants {
+			if got, ok := s.Lookup(key); !ok || !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: Lookup(%q) = %q, want %q", tc.ext, key, got, want)
+			}
+		}
+	}
+}
+
+func TestConfigParsersScalar(t *testing.T) {
+	cases := []struct {
+		ext  string
+		data string
+		want []string
+	}{
+		{".toml", `v = 1_000`, []string{"1000"}},
+		{".toml", `v = -1_000.5e1_0`, []string{"-1000.5e10"}},
+		{".toml", `v = 0x3e8`, []string{"1000"}},
+		{".toml", `v = 0o17`, []string{"15"}},
+		{".toml", `v = 0b1010`, []string{"10"}},
+		{".toml", `v = +inf`, []string{"+inf"}},
+		{".toml", `v = 1979-05-27T07:32:00Z`, []string{"1979-05-27T07:32:00Z"}},
+		{".toml", `v = 'C:\path\n'`, []string{`C:\path\n`}},
+		{".toml", `v = "a\tb"`, []string{"a\tb"}},
+		{".toml", `v = [1_000, 'x\y', "z"]`, []string{"1000", `x\y`, "z"}},
+		{".yaml", `v: 'it''s'`, []string{"it's"}},
+		{".yaml", `v: 1_000`, []string{"1_000"}},
+		{".ini", `v = plain text`, []string{"plain text"}},
+	}
+
+	for _, tc := range cases {
+		config, err := ConfigParsers[tc.ext]([]byte(tc.data))
+		if err != nil {
+			t.Errorf("%s %q: %v", tc.ext, tc.data, err)
+			continue
+		}
+		s := NewMapValueSource("test"+tc.ext, config)
+		if got, ok := s.Lookup("v"); !ok || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s %q: got %q, want %q", tc.ext, tc.data, got, tc.want)
+		}
+	}
+}
+
+func TestConfigParsersError(t *testing.T) {
+	cases := []struct {
+		ext  string
+		data string
+		err  string
+	}{
+		{".json", `{"name": }`, "invalid character"},
+
+		{".toml", "[[servers]]", "line 1: array of tables is not supported"},
+		{".toml", "[build", "line 1: invalid table: [build"},
+		{".toml", "name", "line 1: invalid key/value: name"},
+		{".toml", "name = 1\nname = 2", "line 2: duplicate key 'name'"},
+		{".toml", "name = 1\n[name]", "line 2: key 'name' is not a table"},
+		{".toml", "name = 1\nname.first = 2", "line 2: key 'name' is not a table"},
+		{".toml", `desc = """text"""`, "line 1: multi-line string is not supported"},
+		{".toml", "desc = '''text'''", "line 1: multi-line string is not supported"},
+		{".toml", `name = "app`, "line 1: invalid string"},
+		{".toml", "name = 'app", "line 1: invalid string"},
+		{".toml", "name = 'it''s'", "line 1: invalid string: 'it''s'"},
+		{".toml", "name = app", "line 1: invalid value: app, string must be quoted"},
+		{".toml", "name =", "line 1: missing value"},
+		{".toml", "tags = [a, b]", "line 1: invalid value: a, string must be quoted"},
+		{".toml", "port = 1__000", "line 1: invalid number: 1__000"},
+		{".toml", "port = 1000_", "line 1: invalid number: 1000_"},
+		{".toml", "port = 0xZZ", "line 1: invalid number: 0xZZ"},
+		{".toml", "tags = [\n\"a\",", "line 1: invalid list"},
+		{".toml", "tags = [1,,2]", "line 1: empty item in list: [1,,2]"},
+		{".toml", "tags = [,1]", "line 1: empty item in list: [,1]"},
+		{".toml", "tags = [1, ,2]", "line 1: empty item in list: [1, ,2]"},
+		{".toml", "tags = [[1, 2], [3]]", "line 1: nested list or table in list is not supported"},
+		{".toml", `servers = [{ host = "a" }]`, "line 1: nested list or table in list is not supported"},
+		{".toml", `db = { host = "a"`, "line 1: invalid inline table"},
+		{".toml", `db = { host }`, "line 1: invalid key/value in inline table: host"},
+		{".toml", `db = { host = "a", host = "b" }`, "line 1: duplicate key 'host'"},
+
+		{".yaml", "\tname: app", "line 1: tab is not allowed for indentation"},
+		{".yaml", "name: app\n  port: 80", "line 2: bad indentation"},
+		{".yaml", "build:\n    output: a.out\n  jobs: 4", "line 3: bad indentation"},
+		{".yaml", "desc: |\n  text", "line 1: multi-line scalar is not supported"},
+		{".yaml", "desc: >\n  text", "line 1: multi-line scalar is not supported"},
+		{".yaml", "name: &name app", "line 1: anchor and alias are not supported"},
+		{".yaml", "name: *name", "line 1: anchor and alias are not supported"},
+		{".yaml", "name: app\nname: app", "line 2: duplicate key 'name'"},
+		{".yaml", "name", "line 1: invalid mapping: name"},
+		{".yaml", "- a\n- b", "line 1: top level must be a mapping"},
+		{".yaml", "name: app\n- a", "line 2: unexpected sequence item"},
+		{".yaml", "tags:\n- name: a", "line 2: only scalar is supported in sequence"},
+		{".yaml", "tags:\n- {name: a}", "line 2: only scalar is supported in sequence"},
+		{".yaml", "tags:\n-", "line 2: only scalar is supported in sequence"},
+		{".yaml", `name: "app`, "line 1: invalid string"},
+		{".yaml", "tags: [a,,b]", "line 1: empty item in list: [a,,b]"},
+		{".yaml", "tags: [[a], [b]]", "line 1: nested list or table in list is not supported"},
+		{".yaml", "db: {host: a", "line 1: invalid inline table"},
+		{".yaml", "db: {host:a}", "line 1: invalid key/value in inline table: host:a"},
+		{".yaml", "db: {host: a, host: b}", "line 1: duplicate key 'host'"},
+		{".yaml", "db: {host: &h a}", "line 1: anchor and alias are not supported"},
+
+		{".ini", "[build", "line 1: invalid section: [build"},
+		{".ini", "name", "line 1: invalid key/value: name"},
+		{".ini", "name = 1\n[name]", "line 2: key 'name' is not a table"},
+		{".ini", `name = "app`, "line 1: invalid string"},
+		{".ini", "tags = [,a]", "line 1: empty item in list: [,a]"},
+		{".ini", "tags = [a, [b]]", "line 1: nested list or table in list is not supported"},
+	}
+
+	for _, tc := range cases {
+		_, err := ConfigParsers[tc.ext]([]byte(tc.data))
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s %q: got error %v, want %q", tc.ext, tc.data, err, tc.err)
+		}
+	}
+}
+
+func TestConfigFileSourceError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.json")
+	if err := ioutil.WriteFile(path, []byte(`{"servers": [{"host": "a"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := NewConfigFileSource(path)
+	want := "invalid config file " + path + ": nested list or table in list is not supported: servers"
+	if err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %q", err, want)
+	}
+}
+
+func TestAppRunConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.yaml")
+	data := "name: config\nport: 8080\nbuild:\n  output: config.out\n  jobs: 4\n"
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("GO_CLI_TEST_PORT", "9090")
+	defer os.Unsetenv("GO_CLI_TEST_PORT")
+
+	var name, port, output, jobs string
+	app := &App{
+		Name:        "app",
+		ConfigFlag:  "config",
+		ConfigPaths: []string{filepath.Join(dir, "missing.json"), path},
+		Flags: []*Flag{
+			{Name: "name", DefValue: "default"},
+			{Name: "port", EnvVar: "GO_CLI_TEST_PORT"},
+			{Name: "user", DefValue: "root"},
+		},
+		Commands: []*Command{
+			{
+				Name: "build",
+				Flags: []*Flag{
+					{Name: "o, output"},
+					{Name: "jobs", Required: true},
+				},
+				Action: func(c *Context) {
+					name = c.parent.GetString("name")
+					port = c.parent.GetString("port")
+					output = c.GetString("output")
+					jobs = c.GetString("jobs")
+				},
+			},
+		},
+	}
+
+	// search paths: cli > env > config > default
+	if err := app.RunE([]string{"app", "build", "-o", "cli.out"}); err != nil {
+		t.Fatal(err)
+	}
+	got := []string{name, port, output, jobs, app.Flags[2].GetValue()}
+	want := []string{"config", "9090", "cli.out", "4", "root"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// --config
+	path = filepath.Join(dir, "app.ini")
+	data = "name = ini\n[build]\njobs = 8\n"
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.RunE([]string{"app", "--config", path, "build"}); err != nil {
+		t.Fatal(err)
+	}
+	got = []string{name, output, jobs}
+	want = []string{"ini", "", "8"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// unsupported format
+	err := app.RunE([]string{"app", "--config", "app.xml", "build"})
+	if err == nil || err.Error() != "unsupported config file format: app.xml" {
+		t.Errorf("wrong error: %v", err)
+	}
+
+	// malformed list
+	path = filepath.Join(dir, "app.toml")
+	if err := ioutil.WriteFile(path, []byte("name = [1,,2]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = app.RunE([]string{"app", "--config", path, "build"})
+	if err == nil || err.Error() != "invalid config file "+path+": line 1: empty item in list: [1,,2]" {
+		t.Errorf("wrong error: %v", err)
+	}
+}
+
+func TestAppRunConfigSliceDefault(t *testing.T) {
+	var tags, labels []string
+	app := &App{
+		Name: "app",
+		ValueSources: []ValueSource{
+			NewMapValueSource("app.json", map[string]interface{}{
+				"tags": []interface{}{"b", "c"},
+			}),
+		},
+		Flags: []*Flag{
+			{Name: "tags", Value: &tags, DefValue: "a"},
+			{Name: "labels", Value: &labels, DefValue: "x"},
+		},
+		Action: func(c *Context) {},
+	}
+
+	if err := app.RunE([]string{"app"}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tags, []string{"b", "c"}) {
+		t.Errorf("config should replace default value, got %q", tags)
+	}
+	if !reflect.DeepEqual(labels, []string{"x"}) {
+		t.Errorf("default value is wrong, got %q", labels)
+	}
+}
@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseTOMLConfig parses a subset of TOML: tables, dotted keys, strings,
+// numbers, booleans, dates, arrays and inline tables. Array of tables and
+// multi-line strings are not supported.
+func parseTOMLConfig(data []byte) (map[string]interface{}, error) {
+	config := make(map[string]interface{})
+	table := config
+
+	lines := strings.Split(string(data), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineno := i + 1
+		line := stripConfigComment(lines[i], "#")
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[[") {
+			return nil, fmt.Errorf("line %d: array of tables is not supported", lineno)
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: invalid table: %s", lineno, line)
+			}
+			var err error
+			table, err = configTable(config, splitTOMLKey(line[1:len(line)-1]))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineno, err)
+			}
+			continue
+		}
+
+		n := strings.Index(line, "=")
+		if n < 0 {
+			return nil, fmt.Errorf("line %d: invalid key/value: %s", lineno, line)
+		}
+		keys := splitTOMLKey(line[:n])
+		value := strings.TrimSpace(line[n+1:])
+
+		if strings.HasPrefix(value, `"""`) || strings.HasPrefix(value, "'''") {
+			return nil, fmt.Errorf("line %d: multi-line string is not supported", lineno)
+		}
+
+		// multi-line array
+		for strings.HasPrefix(value, "[") && !tomlBracketsClosed(value) && i+1 < len(lines) {
+			i++
+			value = value + " " + stripConfigComment(lines[i], "#")
+		}
+
+		var v interface{}
+		var err error
+		if strings.HasPrefix(value, "{") {
+			v, err = parseConfigInlineTable(value, "=", parseTOMLScalar)
+		} else {
+			v, err = parseTOMLScalar(value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineno, err)
+		}
+
+		parent, err := configTable(table, keys[:len(keys)-1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineno, err)
+		}
+		key := unquoteConfigKey(keys[len(keys)-1])
+		if _, ok := parent[key]; ok {
+			return nil, fmt.Errorf("line %d: duplicate key '%s'", lineno, key)
+		}
+		parent[key] = v
+	}
+
+	return config, nil
+}
+
+// parseTOMLScalar parses a basic string, a literal string without escapes,
+// a boolean, a number, a date or an array, other bare text is an error.
+// The number is converted to decimal text, e.g. "1_000" and "0x3e8" are "1000".
+func parseTOMLScalar(s string) (interface{}, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, fmt.Errorf("missing value")
+	}
+
+	switch s[0] {
+	case '"':
+		return parseConfigScalar(s)
+	case '\'':
+		if len(s) < 2 || s[len(s)-1] != '\'' || strings.Contains(s[1:len(s)-1], "'") {
+			return nil, fmt.Errorf("invalid string: %s", s)
+		}
+		return s[1 : len(s)-1], nil
+	case '[':
+		return parseConfigList(s, parseTOMLScalar)
+	}
+
+	switch s {
+	case "true", "false", "inf", "+inf", "-inf", "nan", "+nan", "-nan":
+		return s, nil
+	}
+	if ch := s[0]; ch != '+' && ch != '-' && (ch < '0' || ch > '9') {
+		return nil, fmt.Errorf("invalid value: %s, string must be quoted", s)
+	}
+	return parseTOMLNumber(s)
+}
+
+// parseTOMLNumber removes the underscores between digits and converts the hex,
+// octal and binary integer to decimal, a date is returned as it is
+func parseTOMLNumber(s string) (string, error) {
+	if len(s) > 2 && s[0] == '0' && strings.IndexByte("xob", s[1]) >= 0 {
+		n, err := strconv.ParseInt(s, 0, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid number: %s", s)
+		}
+		return strconv.FormatInt(n, 10), nil
+	}
+
+	isDigit := func(ch byte) bool { return ch >= '0' && ch <= '9' }
+	for i := 0; i < len(s); i++ {
+		if s[i] == '_' && (i == 0 || i == len(s)-1 || !isDigit(s[i-1]) || !isDigit(s[i+1])) {
+			return "", fmt.Errorf("invalid number: %s", s)
+		}
+	}
+	return strings.Replace(s, "_", "", -1), nil
+}
+
+// splitTOMLKey splits the dotted key, e.g. `build."output-dir"`
+func splitTOMLKey(key string) []string {
+	var keys []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(key); i++ {
+		ch := key[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '.':
+			keys = append(keys, strings.TrimSpace(key[start:i]))
+			start = i + 1
+		}
+	}
+	return append(keys, strings.TrimSpace(key[start:]))
+}
+
+// tomlBracketsClosed returns true if all brackets outside of quotes are closed
+func tomlBracketsClosed(s string) bool {
+	var quote byte
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case quote != 0:
+			if ch == '\\' && quote == '"' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '[':
+			depth++
+		case ch == ']':
+			depth--
+		}
+	}
+	return depth == 0
+}
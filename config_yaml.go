@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+type yamlLine struct {
+	lineno int
+	indent int
+	text   string
+}
+
+// parseYAMLConfig parses a subset of YAML: block mappings and sequences
+// of scalars, flow sequences like "[a, b]", flow mappings like "{a: 1}"
+// and comments.
+// Anchors, multi-line scalars and multiple documents are not supported.
+func parseYAMLConfig(data []byte) (map[string]interface{}, error) {
+	var lines []yamlLine
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		text := stripConfigComment(line, "#")
+		if text == "" || text == "---" {
+			continue
+		}
+		if strings.HasPrefix(line, "\t") {
+			return nil, fmt.Errorf("line %d: tab is not allowed for indentation", i+1)
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		lines = append(lines, yamlLine{lineno: i + 1, indent: indent, text: text})
+	}
+
+	config := make(map[string]interface{})
+	if len(lines) == 0 {
+		return config, nil
+	}
+
+	value, next, err := parseYAMLBlock(lines, 0, lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if next < len(lines) {
+		return nil, fmt.Errorf("line %d: bad indentation", lines[next].lineno)
+	}
+
+	if m, ok := value.(map[string]interface{}); ok {
+		return m, nil
+	}
+	return nil, fmt.Errorf("line %d: top level must be a mapping", lines[0].lineno)
+}
+
+// parseYAMLBlock parses the lines which have same indent from i,
+// returns the value and the index of next line
+func parseYAMLBlock(lines []yamlLine, i int, indent int) (interface{}, int, error) {
+	if isYAMLSequenceItem(lines[i].text) {
+		var list []interface{}
+		for i < len(lines) && lines[i].indent == indent && isYAMLSequenceItem(lines[i].text) {
+			item := strings.TrimSpace(lines[i].text[1:])
+			if item == "" || yamlMappingKey(item) >= 0 || strings.HasPrefix(item, "{") {
+				return nil, i, fmt.Errorf("line %d: only scalar is supported in sequence", lines[i].lineno)
+			}
+			value, err := parseYAMLScalar(item)
+			if err != nil {
+				return nil, i, fmt.Errorf("line %d: %v", lines[i].lineno, err)
+			}
+			list = append(list, value)
+			i++
+		}
+		return list, i, nil
+	}
+
+	m := make(map[string]interface{})
+	for i < len(lines) && lines[i].indent == indent {
+		line := lines[i]
+		if isYAMLSequenceItem(line.text) {
+			return nil, i, fmt.Errorf("line %d: unexpected sequence item", line.lineno)
+		}
+		n := yamlMappingKey(line.text)
+		if n < 0 {
+			return nil, i, fmt.Errorf("line %d: invalid mapping: %s", line.lineno, line.text)
+		}
+		key := unquoteConfigKey(line.text[:n])
+		rest := strings.TrimSpace(line.text[n+1:])
+		if _, ok := m[key]; ok {
+			return nil, i, fmt.Errorf("line %d: duplicate key '%s'", line.lineno, key)
+		}
+		i++
+
+		if strings.HasPrefix(rest, "{") {
+			value, err := parseConfigInlineTable(rest, ":", parseYAMLScalar)
+			if err != nil {
+				return nil, i, fmt.Errorf("line %d: %v", line.lineno, err)
+			}
+			m[key] = value
+			continue
+		}
+		if rest != "" {
+			value, err := parseYAMLScalar(rest)
+			if err != nil {
+				return nil, i, fmt.Errorf("line %d: %v", line.lineno, err)
+			}
+			m[key] = value
+			continue
+		}
+
+		// nested block, a sequence may have same indent with key
+		if i < len(lines) && (lines[i].indent > indent || (lines[i].indent == indent && isYAMLSequenceItem(lines[i].text))) {
+			value, next, err := parseYAMLBlock(lines, i, lines[i].indent)
+			if err != nil {
+				return nil, next, err
+			}
+			m[key] = value
+			i = next
+		} else {
+			m[key] = nil
+		}
+	}
+
+	if i < len(lines) && lines[i].indent > indent {
+		return nil, i, fmt.Errorf("line %d: bad indentation", lines[i].lineno)
+	}
+	return m, i, nil
+}
+
+func isYAMLSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// yamlMappingKey returns the index of colon after key, or -1
+func yamlMappingKey(text string) int {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		ch := text[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case i == 0 && (ch == '"' || ch == '\''):
+			quote = ch
+		case ch == ':' && (i == len(text)-1 || text[i+1] == ' '):
+			return i
+		}
+	}
+	return -1
+}
+
+func parseYAMLScalar(s string) (interface{}, error) {
+	switch s {
+	case "~", "null", "Null", "NULL":
+		return nil, nil
+	}
+	if strings.HasPrefix(s, "|") || strings.HasPrefix(s, ">") {
+		return nil, fmt.Errorf("multi-line scalar is not supported")
+	}
+	if strings.HasPrefix(s, "&") || strings.HasPrefix(s, "*") {
+		return nil, fmt.Errorf("anchor and alias are not supported")
+	}
+	return parseConfigScalar(s)
+}
@@ -1,27 +1,35 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
-// exit variable for tesing hook
-var exit = os.Exit
-
 // Context is a type that is passed through to
 // each Handler action in a cli application. Context
 // can be used to retrieve context-specific Args and
 // parsed command-line options.
 type Context struct {
-	name     string
-	app      *App
-	command  *Command
-	flags    []*Flag
-	commands []*Command
-	args     []string
-	parent   *Context
+	ctx       context.Context
+	name      string
+	app       *App
+	command   *Command
+	flags     []*Flag
+	commands  []*Command
+	arguments []*Argument
+	args      []string
+	parent    *Context
+	sources   []ValueSource // loaded by app, see valueSources()
+
+	completionDirective CompletionDirective
 }
 
 // Name returns app/command full name
@@ -29,6 +37,15 @@ func (c *Context) Name() string {
 	return c.name
 }
 
+// Context returns the context.Context passed to App.RunContext(),
+// it is cancelled on SIGINT or SIGTERM if App.HandleSignals is true
+func (c *Context) Context() context.Context {
+	if c.ctx != nil {
+		return c.ctx
+	}
+	return context.Background()
+}
+
 // Parent returns parent context if exists
 func (c *Context) Parent() *Context {
 	return c.parent
@@ -45,187 +62,581 @@ func (c *Context) Global() *Context {
 	}
 }
 
+// Lookup returns the named flag in context
+func (c *Context) Lookup(name string) (*Flag, bool) {
+	f := lookupFlag(c.flags, name)
+	return f, f != nil
+}
+
+// lookupE returns the named flag, or FlagNotFoundError
+func (c *Context) lookupE(name string) (*Flag, error) {
+	if f := lookupFlag(c.flags, name); f != nil {
+		return f, nil
+	}
+	return nil, &FlagNotFoundError{Name: name}
+}
+
+// checkStrict panics if the flag is not declared in strict mode
+func (c *Context) checkStrict(err error) {
+	var e *FlagNotFoundError
+	if errors.As(err, &e) && c.app != nil && c.app.Strict {
+		panic(err)
+	}
+}
+
 // IsSet returns flag is visited in cli args
 func (c *Context) IsSet(name string) bool {
-	f := lookupFlag(c.flags, name)
+	f, err := c.lookupE(name)
+	c.checkStrict(err)
 	if f != nil {
-		return f.visited
+		return f.source.Kind == SourceCommandLine
 	}
 	return false
 }
 
 // GetString returns flag value as string
 func (c *Context) GetString(name string) string {
-	f := lookupFlag(c.flags, name)
-	if f != nil {
-		return f.GetValue()
+	v, err := c.GetStringE(name)
+	c.checkStrict(err)
+	return v
+}
+
+// GetStringE returns flag value as string, or an error if the flag is not found
+func (c *Context) GetStringE(name string) (string, error) {
+	f, err := c.lookupE(name)
+	if err != nil {
+		return "", err
 	}
-	return ""
+	return f.GetValue(), nil
 }
 
-// GetStringSlice returns flag value as string slice
+// GetStringSlice returns flag value as string slice,
+// the value of non-slice flag is split by comma
 func (c *Context) GetStringSlice(name string) []string {
-	f := lookupFlag(c.flags, name)
-	if f != nil {
-		return strings.Split(f.GetValue(), ",")
+	v, err := c.GetStringSliceE(name)
+	c.checkStrict(err)
+	return v
+}
+
+// GetStringSliceE returns flag value as string slice, or an error if the flag is not found
+func (c *Context) GetStringSliceE(name string) ([]string, error) {
+	f, err := c.lookupE(name)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	if v, ok := f.wrapValue.(*stringSliceValue); ok {
+		return append([]string(nil), *v.val...), nil
+	}
+	return strings.Split(f.GetValue(), ","), nil
 }
 
 // GetBool returns flag value as bool
 func (c *Context) GetBool(name string) bool {
-	f := lookupFlag(c.flags, name)
-	if f != nil {
-		b, err := strconv.ParseBool(f.GetValue())
-		if err == nil {
-			return b
-		}
+	v, err := c.GetBoolE(name)
+	c.checkStrict(err)
+	return v
+}
+
+// GetBoolE returns flag value as bool, or an error if the flag is not found or invalid
+func (c *Context) GetBoolE(name string) (bool, error) {
+	f, err := c.lookupE(name)
+	if err != nil {
+		return false, err
 	}
-	return false
+	b, err := strconv.ParseBool(f.GetValue())
+	if err != nil {
+		return false, &FlagValueError{Name: name, Err: err}
+	}
+	return b, nil
 }
 
 // GetInt returns flag value as int
 func (c *Context) GetInt(name string) int {
-	f := lookupFlag(c.flags, name)
-	if f != nil {
-		v, err := strconv.ParseInt(f.GetValue(), 0, 0)
-		if err == nil {
-			return int(v)
-		}
+	v, err := c.GetIntE(name)
+	c.checkStrict(err)
+	return v
+}
+
+// GetIntE returns flag value as int, or an error if the flag is not found or invalid
+func (c *Context) GetIntE(name string) (int, error) {
+	f, err := c.lookupE(name)
+	if err != nil {
+		return 0, err
 	}
-	return 0
+	v, err := strconv.ParseInt(f.GetValue(), 0, 0)
+	if err != nil {
+		return 0, &FlagValueError{Name: name, Err: err}
+	}
+	return int(v), nil
 }
 
 // GetInt8 returns flag value as int8
 func (c *Context) GetInt8(name string) int8 {
-	f := lookupFlag(c.flags, name)
-	if f != nil {
-		v, err := strconv.ParseInt(f.GetValue(), 0, 8)
-		if err == nil {
-			return int8(v)
-		}
+	v, err := c.GetInt8E(name)
+	c.checkStrict(err)
+	return v
+}
+
+// GetInt8E returns flag value as int8, or an error if the flag is not found or invalid
+func (c *Context) GetInt8E(name string) (int8, error) {
+	f, err := c.lookupE(name)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseInt(f.GetValue(), 0, 8)
+	if err != nil {
+		return 0, &FlagValueError{Name: name, Err: err}
 	}
-	return 0
+	return int8(v), nil
 }
 
 // GetInt16 returns flag value as int16
 func (c *Context) GetInt16(name string) int16 {
-	f := lookupFlag(c.flags, name)
-	if f != nil {
-		v, err := strconv.ParseInt(f.GetValue(), 0, 16)
-		if err == nil {
-			return int16(v)
-		}
+	v, err := c.GetInt16E(name)
+	c.checkStrict(err)
+	return v
+}
+
+// GetInt16E returns flag value as int16, or an error if the flag is not found or invalid
+func (c *Context) GetInt16E(name string) (int16, error) {
+	f, err := c.lookupE(name)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseInt(f.GetValue(), 0, 16)
+	if err != nil {
+		return 0, &FlagValueError{Name: name, Err: err}
 	}
-	return 0
+	return int16(v), nil
 }
 
 // GetInt32 returns flag value as int32
 func (c *Context) GetInt32(name string) int32 {
-	f := lookupFlag(c.flags, name)
-	if f != nil {
-		v, err := strconv.ParseInt(f.GetValue(), 0, 32)
-		if err == nil {
-			return int32(v)
-		}
+	v, err := c.GetInt32E(name)
+	c.checkStrict(err)
+	return v
+}
+
+// GetInt32E returns flag value as int32, or an error if the flag is not found or invalid
+func (c *Context) GetInt32E(name string) (int32, error) {
+	f, err := c.lookupE(name)
+	if err != nil {
+		return 0, err
 	}
-	return 0
+	v, err := strconv.ParseInt(f.GetValue(), 0, 32)
+	if err != nil {
+		return 0, &FlagValueError{Name: name, Err: err}
+	}
+	return int32(v), nil
 }
 
 // GetInt64 returns flag value as int64
 func (c *Context) GetInt64(name string) int64 {
-	f := lookupFlag(c.flags, name)
-	if f != nil {
-		v, err := strconv.ParseInt(f.GetValue(), 0, 64)
-		if err == nil {
-			return int64(v)
-		}
+	v, err := c.GetInt64E(name)
+	c.checkStrict(err)
+	return v
+}
+
+// GetInt64E returns flag value as int64, or an error if the flag is not found or invalid
+func (c *Context) GetInt64E(name string) (int64, error) {
+	f, err := c.lookupE(name)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseInt(f.GetValue(), 0, 64)
+	if err != nil {
+		return 0, &FlagValueError{Name: name, Err: err}
 	}
-	return 0
+	return int64(v), nil
 }
 
 // GetUint returns flag value as uint
 func (c *Context) GetUint(name string) uint {
-	f := lookupFlag(c.flags, name)
-	if f != nil {
-		v, err := strconv.ParseUint(f.GetValue(), 0, 0)
-		if err == nil {
-			return uint(v)
-		}
+	v, err := c.GetUintE(name)
+	c.checkStrict(err)
+	return v
+}
+
+// GetUintE returns flag value as uint, or an error if the flag is not found or invalid
+func (c *Context) GetUintE(name string) (uint, error) {
+	f, err := c.lookupE(name)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseUint(f.GetValue(), 0, 0)
+	if err != nil {
+		return 0, &FlagValueError{Name: name, Err: err}
 	}
-	return 0
+	return uint(v), nil
 }
 
 // GetUint8 returns flag value as uint8
 func (c *Context) GetUint8(name string) uint8 {
-	f := lookupFlag(c.flags, name)
-	if f != nil {
-		v, err := strconv.ParseUint(f.GetValue(), 0, 8)
-		if err == nil {
-			return uint8(v)
-		}
+	v, err := c.GetUint8E(name)
+	c.checkStrict(err)
+	return v
+}
+
+// GetUint8E returns flag value as uint8, or an error if the flag is not found or invalid
+func (c *Context) GetUint8E(name string) (uint8, error) {
+	f, err := c.lookupE(name)
+	if err != nil {
+		return 0, err
 	}
-	return 0
+	v, err := strconv.ParseUint(f.GetValue(), 0, 8)
+	if err != nil {
+		return 0, &FlagValueError{Name: name, Err: err}
+	}
+	return uint8(v), nil
 }
 
 // GetUint16 returns flag value as uint16
 func (c *Context) GetUint16(name string) uint16 {
-	f := lookupFlag(c.flags, name)
-	if f != nil {
-		v, err := strconv.ParseUint(f.GetValue(), 0, 16)
-		if err == nil {
-			return uint16(v)
-		}
+	v, err := c.GetUint16E(name)
+	c.checkStrict(err)
+	return v
+}
+
+// GetUint16E returns flag value as uint16, or an error if the flag is not found or invalid
+func (c *Context) GetUint16E(name string) (uint16, error) {
+	f, err := c.lookupE(name)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseUint(f.GetValue(), 0, 16)
+	if err != nil {
+		return 0, &FlagValueError{Name: name, Err: err}
 	}
-	return 0
+	return uint16(v), nil
 }
 
 // GetUint32 returns flag value as uint32
 func (c *Context) GetUint32(name string) uint32 {
-	f := lookupFlag(c.flags, name)
-	if f != nil {
-		v, err := strconv.ParseUint(f.GetValue(), 0, 32)
-		if err == nil {
-			return uint32(v)
-		}
+	v, err := c.GetUint32E(name)
+	c.checkStrict(err)
+	return v
+}
+
+// GetUint32E returns flag value as uint32, or an error if the flag is not found or invalid
+func (c *Context) GetUint32E(name string) (uint32, error) {
+	f, err := c.lookupE(name)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseUint(f.GetValue(), 0, 32)
+	if err != nil {
+		return 0, &FlagValueError{Name: name, Err: err}
 	}
-	return 0
+	return uint32(v), nil
 }
 
 // GetUint64 returns flag value as uint64
 func (c *Context) GetUint64(name string) uint64 {
-	f := lookupFlag(c.flags, name)
-	if f != nil {
-		v, err := strconv.ParseUint(f.GetValue(), 0, 64)
-		if err == nil {
-			return uint64(v)
-		}
+	v, err := c.GetUint64E(name)
+	c.checkStrict(err)
+	return v
+}
+
+// GetUint64E returns flag value as uint64, or an error if the flag is not found or invalid
+func (c *Context) GetUint64E(name string) (uint64, error) {
+	f, err := c.lookupE(name)
+	if err != nil {
+		return 0, err
 	}
-	return 0
+	v, err := strconv.ParseUint(f.GetValue(), 0, 64)
+	if err != nil {
+		return 0, &FlagValueError{Name: name, Err: err}
+	}
+	return uint64(v), nil
 }
 
 // GetFloat32 returns flag value as float32
 func (c *Context) GetFloat32(name string) float32 {
-	f := lookupFlag(c.flags, name)
-	if f != nil {
-		v, err := strconv.ParseFloat(f.GetValue(), 32)
-		if err == nil {
-			return float32(v)
-		}
+	v, err := c.GetFloat32E(name)
+	c.checkStrict(err)
+	return v
+}
+
+// GetFloat32E returns flag value as float32, or an error if the flag is not found or invalid
+func (c *Context) GetFloat32E(name string) (float32, error) {
+	f, err := c.lookupE(name)
+	if err != nil {
+		return 0, err
 	}
-	return 0
+	v, err := strconv.ParseFloat(f.GetValue(), 32)
+	if err != nil {
+		return 0, &FlagValueError{Name: name, Err: err}
+	}
+	return float32(v), nil
 }
 
 // GetFloat64 returns flag value as float64
 func (c *Context) GetFloat64(name string) float64 {
-	f := lookupFlag(c.flags, name)
-	if f != nil {
-		v, err := strconv.ParseFloat(f.GetValue(), 64)
-		if err == nil {
-			return float64(v)
-		}
+	v, err := c.GetFloat64E(name)
+	c.checkStrict(err)
+	return v
+}
+
+// GetFloat64E returns flag value as float64, or an error if the flag is not found or invalid
+func (c *Context) GetFloat64E(name string) (float64, error) {
+	f, err := c.lookupE(name)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseFloat(f.GetValue(), 64)
+	if err != nil {
+		return 0, &FlagValueError{Name: name, Err: err}
+	}
+	return float64(v), nil
+}
+
+// GetIntSlice returns flag value as int slice
+func (c *Context) GetIntSlice(name string) []int {
+	v, err := c.GetIntSliceE(name)
+	c.checkStrict(err)
+	return v
+}
+
+// GetIntSliceE returns flag value as int slice, or an error if the flag is not found or has a different type
+func (c *Context) GetIntSliceE(name string) ([]int, error) {
+	f, err := c.lookupE(name)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := f.wrapValue.(*intSliceValue); ok {
+		return append([]int(nil), *v.val...), nil
+	}
+	return nil, &FlagValueError{Name: name, Err: fmt.Errorf("not a int slice")}
+}
+
+// GetUintSlice returns flag value as uint slice
+func (c *Context) GetUintSlice(name string) []uint {
+	v, err := c.GetUintSliceE(name)
+	c.checkStrict(err)
+	return v
+}
+
+// GetUintSliceE returns flag value as uint slice, or an error if the flag is not found or has a different type
+func (c *Context) GetUintSliceE(name string) ([]uint, error) {
+	f, err := c.lookupE(name)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := f.wrapValue.(*uintSliceValue); ok {
+		return append([]uint(nil), *v.val...), nil
 	}
-	return 0
+	return nil, &FlagValueError{Name: name, Err: fmt.Errorf("not a uint slice")}
+}
+
+// GetFloat64Slice returns flag value as float64 slice
+func (c *Context) GetFloat64Slice(name string) []float64 {
+	v, err := c.GetFloat64SliceE(name)
+	c.checkStrict(err)
+	return v
+}
+
+// GetFloat64SliceE returns flag value as float64 slice, or an error if the flag is not found or has a different type
+func (c *Context) GetFloat64SliceE(name string) ([]float64, error) {
+	f, err := c.lookupE(name)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := f.wrapValue.(*float64SliceValue); ok {
+		return append([]float64(nil), *v.val...), nil
+	}
+	return nil, &FlagValueError{Name: name, Err: fmt.Errorf("not a float64 slice")}
+}
+
+// GetTime returns flag value as time.Time
+func (c *Context) GetTime(name string) time.Time {
+	v, err := c.GetTimeE(name)
+	c.checkStrict(err)
+	return v
+}
+
+// GetTimeE returns flag value as time.Time, or an error if the flag is not found or has a different type
+func (c *Context) GetTimeE(name string) (time.Time, error) {
+	f, err := c.lookupE(name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if v, ok := f.wrapValue.(*timeValue); ok {
+		return *v.val, nil
+	}
+	return time.Time{}, &FlagValueError{Name: name, Err: fmt.Errorf("not a time.Time")}
+}
+
+// GetDuration returns flag value as time.Duration
+func (c *Context) GetDuration(name string) time.Duration {
+	v, err := c.GetDurationE(name)
+	c.checkStrict(err)
+	return v
+}
+
+// GetDurationE returns flag value as time.Duration, or an error if the flag is not found or has a different type
+func (c *Context) GetDurationE(name string) (time.Duration, error) {
+	f, err := c.lookupE(name)
+	if err != nil {
+		return 0, err
+	}
+	if v, ok := f.wrapValue.(*timeDurationValue); ok {
+		return *v.val, nil
+	}
+	return 0, &FlagValueError{Name: name, Err: fmt.Errorf("not a time.Duration")}
+}
+
+// GetLocation returns flag value as *time.Location
+func (c *Context) GetLocation(name string) *time.Location {
+	v, err := c.GetLocationE(name)
+	c.checkStrict(err)
+	return v
+}
+
+// GetLocationE returns flag value as *time.Location, or an error if the flag is not found or has a different type
+func (c *Context) GetLocationE(name string) (*time.Location, error) {
+	f, err := c.lookupE(name)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := f.wrapValue.(*timeLocationValue); ok {
+		return v.val, nil
+	}
+	return nil, &FlagValueError{Name: name, Err: fmt.Errorf("not a *time.Location")}
+}
+
+// GetIP returns flag value as net.IP
+func (c *Context) GetIP(name string) net.IP {
+	v, err := c.GetIPE(name)
+	c.checkStrict(err)
+	return v
+}
+
+// GetIPE returns flag value as net.IP, or an error if the flag is not found or has a different type
+func (c *Context) GetIPE(name string) (net.IP, error) {
+	f, err := c.lookupE(name)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := f.wrapValue.(*ipValue); ok {
+		return *v.val, nil
+	}
+	return nil, &FlagValueError{Name: name, Err: fmt.Errorf("not a net.IP")}
+}
+
+// GetIPSlice returns flag value as net.IP slice
+func (c *Context) GetIPSlice(name string) []net.IP {
+	v, err := c.GetIPSliceE(name)
+	c.checkStrict(err)
+	return v
+}
+
+// GetIPSliceE returns flag value as net.IP slice, or an error if the flag is not found or has a different type
+func (c *Context) GetIPSliceE(name string) ([]net.IP, error) {
+	f, err := c.lookupE(name)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := f.wrapValue.(*ipSliceValue); ok {
+		return append([]net.IP(nil), *v.val...), nil
+	}
+	return nil, &FlagValueError{Name: name, Err: fmt.Errorf("not a net.IP slice")}
+}
+
+// GetIPMask returns flag value as net.IPMask
+func (c *Context) GetIPMask(name string) net.IPMask {
+	v, err := c.GetIPMaskE(name)
+	c.checkStrict(err)
+	return v
+}
+
+// GetIPMaskE returns flag value as net.IPMask, or an error if the flag is not found or has a different type
+func (c *Context) GetIPMaskE(name string) (net.IPMask, error) {
+	f, err := c.lookupE(name)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := f.wrapValue.(*ipMaskValue); ok {
+		return *v.val, nil
+	}
+	return nil, &FlagValueError{Name: name, Err: fmt.Errorf("not a net.IPMask")}
+}
+
+// GetIPNet returns flag value as *net.IPNet
+func (c *Context) GetIPNet(name string) *net.IPNet {
+	v, err := c.GetIPNetE(name)
+	c.checkStrict(err)
+	return v
+}
+
+// GetIPNetE returns flag value as *net.IPNet, or an error if the flag is not found or has a different type
+func (c *Context) GetIPNetE(name string) (*net.IPNet, error) {
+	f, err := c.lookupE(name)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := f.wrapValue.(*ipNetValue); ok {
+		val := *v.val
+		return &val, nil
+	}
+	return nil, &FlagValueError{Name: name, Err: fmt.Errorf("not a *net.IPNet")}
+}
+
+// GetIPNetSlice returns flag value as net.IPNet slice
+func (c *Context) GetIPNetSlice(name string) []net.IPNet {
+	v, err := c.GetIPNetSliceE(name)
+	c.checkStrict(err)
+	return v
+}
+
+// GetIPNetSliceE returns flag value as net.IPNet slice, or an error if the flag is not found or has a different type
+func (c *Context) GetIPNetSliceE(name string) ([]net.IPNet, error) {
+	f, err := c.lookupE(name)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := f.wrapValue.(*ipNetSliceValue); ok {
+		return append([]net.IPNet(nil), *v.val...), nil
+	}
+	return nil, &FlagValueError{Name: name, Err: fmt.Errorf("not a net.IPNet slice")}
+}
+
+// GetURL returns flag value as *url.URL
+func (c *Context) GetURL(name string) *url.URL {
+	v, err := c.GetURLE(name)
+	c.checkStrict(err)
+	return v
+}
+
+// GetURLE returns flag value as *url.URL, or an error if the flag is not found or has a different type
+func (c *Context) GetURLE(name string) (*url.URL, error) {
+	f, err := c.lookupE(name)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := f.wrapValue.(*urlValue); ok {
+		val := *v.val
+		return &val, nil
+	}
+	return nil, &FlagValueError{Name: name, Err: fmt.Errorf("not a *url.URL")}
+}
+
+// GetURLSlice returns flag value as url.URL slice
+func (c *Context) GetURLSlice(name string) []url.URL {
+	v, err := c.GetURLSliceE(name)
+	c.checkStrict(err)
+	return v
+}
+
+// GetURLSliceE returns flag value as url.URL slice, or an error if the flag is not found or has a different type
+func (c *Context) GetURLSliceE(name string) ([]url.URL, error) {
+	f, err := c.lookupE(name)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := f.wrapValue.(*urlSliceValue); ok {
+		return append([]url.URL(nil), *v.val...), nil
+	}
+	return nil, &FlagValueError{Name: name, Err: fmt.Errorf("not a url.URL slice")}
 }
 
 // NArg returns number of non-flag arguments
@@ -233,8 +644,11 @@ func (c *Context) NArg() int {
 	return len(c.args)
 }
 
-// Arg returns the i'th non-flag argument
+// Arg returns the i'th non-flag argument, returns "" if out of range
 func (c *Context) Arg(n int) string {
+	if n < 0 || n >= len(c.args) {
+		return ""
+	}
 	return c.args[n]
 }
 
@@ -243,40 +657,176 @@ func (c *Context) Args() []string {
 	return c.args
 }
 
+// Stdin returns the standard input of app
+func (c *Context) Stdin() io.Reader {
+	if c.app != nil && c.app.Stdin != nil {
+		return c.app.Stdin
+	}
+	return os.Stdin
+}
+
+// Stdout returns the standard output of app
+func (c *Context) Stdout() io.Writer {
+	if c.app != nil && c.app.Stdout != nil {
+		return c.app.Stdout
+	}
+	return os.Stdout
+}
+
+// Stderr returns the standard error of app
+func (c *Context) Stderr() io.Writer {
+	if c.app != nil && c.app.Stderr != nil {
+		return c.app.Stderr
+	}
+	return os.Stderr
+}
+
+func (c *Context) exit(code int) {
+	if c.app != nil && c.app.Exit != nil {
+		c.app.Exit(code)
+	} else {
+		os.Exit(code)
+	}
+}
+
 // ShowHelp shows help and
 func (c *Context) ShowHelp() {
 	if c.command != nil {
-		c.command.ShowHelp(newCommandHelpContext(c.name, c.command, c.app))
+		helpCtx := newCommandHelpContext(c.name, c.command, c.app)
+		helpCtx.InheritedFlags = c.inheritedFlags()
+		helpCtx.Writer = c.Stdout()
+		c.command.ShowHelp(helpCtx)
 	} else {
-		c.app.ShowHelp(newAppHelpContext(c.name, c.app))
+		helpCtx := newAppHelpContext(c.name, c.app)
+		helpCtx.Writer = c.Stdout()
+		c.app.ShowHelp(helpCtx)
 	}
 }
 
 // ShowHelpAndExit shows help and exit
 func (c *Context) ShowHelpAndExit(code int) {
 	c.ShowHelp()
-	exit(code)
+	c.exit(code)
 }
 
-// ShowError shows error and exit(1)
+// ShowError shows error and exit(1), or exit with the code if err is an ExitCoder
 func (c *Context) ShowError(err error) {
-	w := os.Stderr
+	w := c.Stderr()
 	fmt.Fprintln(w, err)
 	fmt.Fprintln(w, fmt.Sprintf("\nRun '%s --help' for more information", c.name))
-	exit(1)
+	c.exit(exitCode(err))
+}
+
+// inheritedFlags returns the persistent flags inherited from parent contexts
+func (c *Context) inheritedFlags() []*Flag {
+	if c.command == nil {
+		return nil
+	}
+	var flags []*Flag
+	for _, f := range c.flags {
+		if lookupFlag(c.command.Flags, f.Names()[0]) != f {
+			flags = append(flags, f)
+		}
+	}
+	return flags
+}
+
+// persistentFlags returns the persistent flags, which are inherited by subcommands
+func (c *Context) persistentFlags() []*Flag {
+	var flags []*Flag
+	for _, f := range c.flags {
+		if f.Persistent {
+			flags = append(flags, f)
+		}
+	}
+	return flags
 }
 
-func (c *Context) handlePanic() {
-	if e := recover(); e != nil {
-		if c.app.OnActionPanic != nil {
-			err, ok := e.(error)
-			if !ok {
-				err = fmt.Errorf("%v", e)
+// checkRequiredFlags checks all required flags in context and parent contexts are set
+func (c *Context) checkRequiredFlags() error {
+	var names []string
+	checked := make(map[*Flag]bool)
+	for ctx := c; ctx != nil; ctx = ctx.parent {
+		for _, f := range ctx.flags {
+			if checked[f] {
+				continue // persistent flag is inherited by child context
 			}
-			c.app.OnActionPanic(c, err)
-		} else {
-			os.Stderr.WriteString(fmt.Sprintf("fatal: %v\n", e))
+			checked[f] = true
+			if f.Required && f.source.Kind <= SourceDefault {
+				names = append(names, f.displayName())
+			}
+		}
+	}
+	if len(names) > 0 {
+		return &UsageError{Name: c.name, Err: &RequiredFlagsError{Names: names}}
+	}
+	return nil
+}
+
+func actionFunc(action func(*Context), actionE func(*Context) error) ActionFunc {
+	if actionE != nil {
+		return actionE
+	}
+	if action != nil {
+		return func(c *Context) error {
+			action(c)
+			return nil
+		}
+	}
+	return nil
+}
+
+// runBefore calls the Before hook of app/command
+func (c *Context) runBefore(before func(*Context) error) error {
+	if before == nil {
+		return nil
+	}
+	return before(c)
+}
+
+// runAfter calls the After hook of app/command, the error of After
+// is returned only if there is no error from subcommands and action
+func (c *Context) runAfter(after func(*Context) error, err *error) {
+	if after == nil {
+		return
+	}
+	if e := after(c); e != nil && *err == nil {
+		*err = e
+	}
+}
+
+func (c *Context) runAction(action ActionFunc) error {
+	return c.wrapAction(action)(c)
+}
+
+func (c *Context) handlePanic(e interface{}) error {
+	err, ok := e.(error)
+	if !ok {
+		err = fmt.Errorf("%v", e)
+	}
+	if c.app != nil && c.app.OnActionPanic != nil {
+		c.app.OnActionPanic(c, err)
+	}
+	return &PanicError{Value: e}
+}
+
+// handleError shows the error returned by RunE() and exit(1),
+// or exit with the code if err is an ExitCoder
+func (c *Context) handleError(err error) {
+	w := c.Stderr()
+
+	var usageErr *UsageError
+	var panicErr *PanicError
+	if errors.As(err, &usageErr) {
+		fmt.Fprintln(w, err)
+		fmt.Fprintln(w, fmt.Sprintf("\nRun '%s --help' for more information", usageErr.Name))
+	} else if errors.As(err, &panicErr) {
+		// the panic is already handled by app.OnActionPanic
+		if c.app == nil || c.app.OnActionPanic == nil {
+			fmt.Fprintf(w, "fatal: %v\n", panicErr.Value)
 		}
-		exit(1)
+	} else if msg := err.Error(); msg != "" {
+		fmt.Fprintln(w, msg)
 	}
+	c.exit(exitCode(err))
 }
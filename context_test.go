@@ -1,8 +1,12 @@
 package cli
 
 import (
+	"errors"
+	"net"
+	"net/url"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestContextGet(t *testing.T) {
@@ -85,6 +89,138 @@ func TestContextGet(t *testing.T) {
 	}
 }
 
+func TestContextGetTyped(t *testing.T) {
+	c := &Context{
+		flags: []*Flag{
+			{Name: "strings", Value: new([]string)},
+			{Name: "ints", Value: new([]int)},
+			{Name: "floats", Value: new([]float64)},
+			{Name: "time", Value: new(time.Time)},
+			{Name: "duration", Value: new(time.Duration)},
+			{Name: "ip", Value: new(net.IP)},
+			{Name: "ipnet", Value: new(net.IPNet)},
+			{Name: "url", Value: new(url.URL)},
+			{Name: "string"},
+		},
+	}
+
+	// initialize flags
+	for _, f := range c.flags {
+		f.initialize()
+	}
+
+	lookupFlag(c.flags, "strings").SetValue("a,b")
+	lookupFlag(c.flags, "strings").SetValue("c")
+	lookupFlag(c.flags, "ints").SetValue("1")
+	lookupFlag(c.flags, "ints").SetValue("2")
+	lookupFlag(c.flags, "floats").SetValue("1.5")
+	lookupFlag(c.flags, "time").SetValue("2017-05-13")
+	lookupFlag(c.flags, "duration").SetValue("1m30s")
+	lookupFlag(c.flags, "ip").SetValue("127.0.0.1")
+	lookupFlag(c.flags, "ipnet").SetValue("10.0.0.0/8")
+	lookupFlag(c.flags, "url").SetValue("https://example.com/a,b")
+	lookupFlag(c.flags, "string").SetValue("x")
+
+	if got := c.GetStringSlice("strings"); !reflect.DeepEqual(got, []string{"a,b", "c"}) {
+		t.Errorf("GetStringSlice is wrong, got: %q", got)
+	}
+	if got := c.GetIntSlice("ints"); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("GetIntSlice is wrong, got: %v", got)
+	}
+	if got := c.GetFloat64Slice("floats"); !reflect.DeepEqual(got, []float64{1.5}) {
+		t.Errorf("GetFloat64Slice is wrong, got: %v", got)
+	}
+	if got := c.GetTime("time"); !got.Equal(time.Date(2017, 5, 13, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("GetTime is wrong, got: %v", got)
+	}
+	if got := c.GetDuration("duration"); got != 90*time.Second {
+		t.Errorf("GetDuration is wrong, got: %v", got)
+	}
+	if got := c.GetIP("ip"); !got.Equal(net.IPv4(127, 0, 0, 1)) {
+		t.Errorf("GetIP is wrong, got: %v", got)
+	}
+	if got := c.GetIPNet("ipnet"); got == nil || got.String() != "10.0.0.0/8" {
+		t.Errorf("GetIPNet is wrong, got: %v", got)
+	}
+	if got := c.GetURL("url"); got == nil || got.Host != "example.com" || got.Path != "/a,b" {
+		t.Errorf("GetURL is wrong, got: %v", got)
+	}
+
+	// wrong type
+	if c.GetDuration("string") != 0 || c.GetIntSlice("string") != nil || c.GetURL("string") != nil {
+		t.Error("typed getter returns value for wrong type")
+	}
+	if got := c.GetStringSlice("string"); !reflect.DeepEqual(got, []string{"x"}) {
+		t.Errorf("GetStringSlice is wrong for string flag, got: %q", got)
+	}
+}
+
+func TestContextGetE(t *testing.T) {
+	c := &Context{
+		flags: []*Flag{
+			{Name: "n, name"},
+			{Name: "timeout", Value: new(time.Duration)},
+		},
+	}
+
+	// initialize flags
+	for _, f := range c.flags {
+		f.initialize()
+	}
+	lookupFlag(c.flags, "name").SetValue("abc")
+
+	if f, ok := c.Lookup("n"); !ok || f.Name != "n, name" {
+		t.Errorf("Lookup is wrong, got: %v", f)
+	}
+	if _, ok := c.Lookup("typo"); ok {
+		t.Error("Lookup found undeclared flag")
+	}
+
+	if v, err := c.GetStringE("name"); err != nil || v != "abc" {
+		t.Errorf("GetStringE is wrong, got: %q, %v", v, err)
+	}
+
+	var notFound *FlagNotFoundError
+	if _, err := c.GetIntE("typo"); !errors.As(err, &notFound) || err.Error() != "flag is not declared: typo" {
+		t.Errorf("GetIntE is wrong, got: %v", err)
+	}
+
+	var invalid *FlagValueError
+	if _, err := c.GetIntE("name"); !errors.As(err, &invalid) || invalid.Name != "name" {
+		t.Errorf("GetIntE is wrong, got: %v", err)
+	}
+	if _, err := c.GetURLE("timeout"); err == nil || err.Error() != "invalid value of flag 'timeout': not a *url.URL" {
+		t.Errorf("GetURLE is wrong, got: %v", err)
+	}
+
+	// not strict
+	if c.GetInt("typo") != 0 || c.GetInt("name") != 0 {
+		t.Error("GetInt is wrong")
+	}
+}
+
+func TestContextStrict(t *testing.T) {
+	app := &App{
+		Name:   "app",
+		Strict: true,
+		Flags: []*Flag{
+			{Name: "name"},
+		},
+		ActionE: func(c *Context) error {
+			c.GetString("name")
+			c.GetString("typo")
+			return nil
+		},
+		OnActionPanic: func(c *Context, err error) {},
+	}
+
+	err := app.RunE([]string{"app"})
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) || err.Error() != "flag is not declared: typo" {
+		t.Errorf("wrong error: %v", err)
+	}
+}
+
 func TestContextArg(t *testing.T) {
 	c := &Context{
 		args: []string{"a", "b", "c"},
@@ -96,6 +232,9 @@ func TestContextArg(t *testing.T) {
 	if c.Arg(0) != "a" {
 		t.Error("Arg(0) != 'a'")
 	}
+	if c.Arg(3) != "" {
+		t.Error("Arg(3) != ''")
+	}
 	if !reflect.DeepEqual(c.Args(), c.args) {
 		t.Error("Args() is wrong")
 	}
@@ -0,0 +1,326 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GenMarkdownTree writes the Markdown docs of app and all visible subcommands into dir,
+// the files are named like "app.md" and "app-command.md"
+func (a *App) GenMarkdownTree(dir string) error {
+	return a.walkHelpContexts(func(ctx, parent *HelpContext) error {
+		return writeDocFile(filepath.Join(dir, manPageName(ctx.Name)+".md"), func(w io.Writer) error {
+			return genMarkdown(w, ctx, parent)
+		})
+	})
+}
+
+// GenMarkdown writes the Markdown doc of app to w
+func (a *App) GenMarkdown(w io.Writer) error {
+	a.initialize()
+	return genMarkdown(w, newAppHelpContext(a.Name, a), nil)
+}
+
+// GenReSTTree writes the reStructuredText docs of app and all visible subcommands into dir,
+// the files are named like "app.rst" and "app-command.rst"
+func (a *App) GenReSTTree(dir string) error {
+	return a.walkHelpContexts(func(ctx, parent *HelpContext) error {
+		return writeDocFile(filepath.Join(dir, manPageName(ctx.Name)+".rst"), func(w io.Writer) error {
+			return genReST(w, ctx, parent)
+		})
+	})
+}
+
+// GenReST writes the reStructuredText doc of app to w
+func (a *App) GenReST(w io.Writer) error {
+	a.initialize()
+	return genReST(w, newAppHelpContext(a.Name, a), nil)
+}
+
+// walkHelpContexts calls fn for app and all visible subcommands recursively,
+// parent is nil for app
+func (a *App) walkHelpContexts(fn func(ctx, parent *HelpContext) error) error {
+	a.initialize()
+
+	var walk func(ctx, parent *HelpContext) error
+	walk = func(ctx, parent *HelpContext) error {
+		if err := fn(ctx, parent); err != nil {
+			return err
+		}
+		for _, cmd := range ctx.VisibleCommands() {
+			cmd.initialize()
+			name := ctx.Name + " " + cmd.Names()[0]
+			child := newCommandHelpContext(name, cmd, a)
+			child.InheritedFlags = ctx.commandInheritedFlags()
+			if err := walk(child, ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return walk(newAppHelpContext(a.Name, a), nil)
+}
+
+func writeDocFile(filename string, gen func(w io.Writer) error) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := gen(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func genMarkdown(w io.Writer, ctx *HelpContext, parent *HelpContext) error {
+	buf := new(bytes.Buffer)
+
+	fmt.Fprintf(buf, "# %s\n\n", ctx.Name)
+	if ctx.Usage != "" {
+		fmt.Fprintf(buf, "%s\n\n", ctx.Usage)
+	}
+
+	fmt.Fprintf(buf, "## Synopsis\n\n```\n")
+	for _, usage := range ctx.UsageTextLines() {
+		fmt.Fprintf(buf, "%s %s\n", ctx.Name, usage)
+	}
+	fmt.Fprintf(buf, "```\n\n")
+
+	if description := strings.TrimSpace(ctx.Description); description != "" {
+		fmt.Fprintf(buf, "## Description\n\n%s\n\n", description)
+	}
+
+	if len(ctx.Arguments) > 0 {
+		fmt.Fprintf(buf, "## Arguments\n\n")
+		fmt.Fprintf(buf, "| Argument | Description | Required |\n")
+		fmt.Fprintf(buf, "| -------- | ----------- | -------- |\n")
+		for _, a := range ctx.Arguments {
+			fmt.Fprintf(buf, "| `%s` | %s | %s |\n", makeArgumentLabel(a), markdownCell(a.Usage), docYesNo(a.Required))
+		}
+		fmt.Fprintf(buf, "\n")
+	}
+
+	markdownFlagsTable(buf, "Options", ctx.VisibleFlags())
+	markdownFlagsTable(buf, "Inherited Options", ctx.VisibleInheritedFlags())
+
+	if commands := ctx.VisibleCommands(); len(commands) > 0 {
+		fmt.Fprintf(buf, "## Commands\n\n")
+		fmt.Fprintf(buf, "| Command | Description |\n")
+		fmt.Fprintf(buf, "| ------- | ----------- |\n")
+		for _, c := range commands {
+			page := manPageName(ctx.Name+" "+c.Names()[0]) + ".md"
+			fmt.Fprintf(buf, "| [%s](%s) | %s |\n", makeCommandLabel(c), page, markdownCell(c.Usage))
+		}
+		fmt.Fprintf(buf, "\n")
+	}
+
+	if examples := ctx.ExampleLines(); len(examples) > 0 {
+		fmt.Fprintf(buf, "## Examples\n\n```\n%s\n```\n\n", strings.Join(examples, "\n"))
+	}
+
+	if authors := ctx.AuthorLines(); len(authors) > 0 {
+		fmt.Fprintf(buf, "## Authors\n\n")
+		for _, line := range authors {
+			fmt.Fprintf(buf, "* %s\n", line)
+		}
+		fmt.Fprintf(buf, "\n")
+	}
+
+	var seeAlso []string
+	if parent != nil {
+		seeAlso = append(seeAlso, fmt.Sprintf("[%s](%s.md)%s", parent.Name, manPageName(parent.Name), docUsageSuffix(parent.Usage)))
+	}
+	for _, c := range ctx.VisibleCommands() {
+		name := ctx.Name + " " + c.Names()[0]
+		seeAlso = append(seeAlso, fmt.Sprintf("[%s](%s.md)%s", name, manPageName(name), docUsageSuffix(c.Usage)))
+	}
+	seeAlso = append(seeAlso, ctx.SeeAlsoLines()...)
+	if len(seeAlso) > 0 {
+		fmt.Fprintf(buf, "## See Also\n\n")
+		for _, line := range seeAlso {
+			fmt.Fprintf(buf, "* %s\n", line)
+		}
+		fmt.Fprintf(buf, "\n")
+	}
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func genReST(w io.Writer, ctx *HelpContext, parent *HelpContext) error {
+	buf := new(bytes.Buffer)
+
+	fmt.Fprintf(buf, "%s\n%s\n\n", ctx.Name, strings.Repeat("=", len(ctx.Name)))
+	if ctx.Usage != "" {
+		fmt.Fprintf(buf, "%s\n\n", ctx.Usage)
+	}
+
+	restSection(buf, "Synopsis")
+	fmt.Fprintf(buf, "::\n\n")
+	for _, usage := range ctx.UsageTextLines() {
+		fmt.Fprintf(buf, "   %s %s\n", ctx.Name, usage)
+	}
+	fmt.Fprintf(buf, "\n")
+
+	if description := strings.TrimSpace(ctx.Description); description != "" {
+		restSection(buf, "Description")
+		fmt.Fprintf(buf, "%s\n\n", description)
+	}
+
+	if len(ctx.Arguments) > 0 {
+		restSection(buf, "Arguments")
+		rows := [][]string{{"Argument", "Description", "Required"}}
+		for _, a := range ctx.Arguments {
+			rows = append(rows, []string{restLiteral(makeArgumentLabel(a)), a.Usage, docYesNo(a.Required)})
+		}
+		restListTable(buf, rows)
+	}
+
+	restFlagsTable(buf, "Options", ctx.VisibleFlags())
+	restFlagsTable(buf, "Inherited Options", ctx.VisibleInheritedFlags())
+
+	if commands := ctx.VisibleCommands(); len(commands) > 0 {
+		restSection(buf, "Commands")
+		rows := [][]string{{"Command", "Description"}}
+		for _, c := range commands {
+			page := manPageName(ctx.Name+" "+c.Names()[0]) + ".rst"
+			rows = append(rows, []string{restLink(makeCommandLabel(c), page), c.Usage})
+		}
+		restListTable(buf, rows)
+	}
+
+	if examples := ctx.ExampleLines(); len(examples) > 0 {
+		restSection(buf, "Examples")
+		fmt.Fprintf(buf, ".. code-block:: bash\n\n")
+		for _, line := range examples {
+			fmt.Fprintf(buf, "   %s\n", line)
+		}
+		fmt.Fprintf(buf, "\n")
+	}
+
+	if authors := ctx.AuthorLines(); len(authors) > 0 {
+		restSection(buf, "Authors")
+		for _, line := range authors {
+			fmt.Fprintf(buf, "* %s\n", line)
+		}
+		fmt.Fprintf(buf, "\n")
+	}
+
+	var seeAlso []string
+	if parent != nil {
+		seeAlso = append(seeAlso, restLink(parent.Name, manPageName(parent.Name)+".rst")+docUsageSuffix(parent.Usage))
+	}
+	for _, c := range ctx.VisibleCommands() {
+		name := ctx.Name + " " + c.Names()[0]
+		seeAlso = append(seeAlso, restLink(name, manPageName(name)+".rst")+docUsageSuffix(c.Usage))
+	}
+	seeAlso = append(seeAlso, ctx.SeeAlsoLines()...)
+	if len(seeAlso) > 0 {
+		restSection(buf, "See Also")
+		for _, line := range seeAlso {
+			fmt.Fprintf(buf, "* %s\n", line)
+		}
+		fmt.Fprintf(buf, "\n")
+	}
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func markdownFlagsTable(buf *bytes.Buffer, title string, flags []*Flag) {
+	if len(flags) == 0 {
+		return
+	}
+	fmt.Fprintf(buf, "## %s\n\n", title)
+	fmt.Fprintf(buf, "| Option | Description | Default | Env |\n")
+	fmt.Fprintf(buf, "| ------ | ----------- | ------- | --- |\n")
+	for _, f := range flags {
+		fmt.Fprintf(buf, "| `%s` | %s | %s | %s |\n",
+			makeFlagLabel(f, false), markdownCell(makeFlagDescription(f)),
+			markdownCode(f.DefValue), markdownCode(f.EnvVar))
+	}
+	fmt.Fprintf(buf, "\n")
+}
+
+func restFlagsTable(buf *bytes.Buffer, title string, flags []*Flag) {
+	if len(flags) == 0 {
+		return
+	}
+	restSection(buf, title)
+	rows := [][]string{{"Option", "Description", "Default", "Env"}}
+	for _, f := range flags {
+		rows = append(rows, []string{
+			restLiteral(makeFlagLabel(f, false)), makeFlagDescription(f),
+			restLiteral(f.DefValue), restLiteral(f.EnvVar),
+		})
+	}
+	restListTable(buf, rows)
+}
+
+func docYesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func docUsageSuffix(usage string) string {
+	if usage == "" {
+		return ""
+	}
+	return " - " + usage
+}
+
+// markdownCell escapes text in a cell of Markdown table
+func markdownCell(s string) string {
+	s = strings.Replace(s, "|", `\|`, -1)
+	return strings.Replace(s, "\n", " ", -1)
+}
+
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + markdownCell(s) + "`"
+}
+
+func restSection(buf *bytes.Buffer, title string) {
+	fmt.Fprintf(buf, "%s\n%s\n\n", title, strings.Repeat("-", len(title)))
+}
+
+func restLiteral(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "``" + s + "``"
+}
+
+// restLink returns an anonymous hyperlink, so the same text can be linked twice
+func restLink(text, target string) string {
+	return "`" + text + " <" + target + ">`__"
+}
+
+func restListTable(buf *bytes.Buffer, rows [][]string) {
+	fmt.Fprintf(buf, ".. list-table::\n   :header-rows: 1\n\n")
+	for _, row := range rows {
+		for i, cell := range row {
+			prefix := "     -"
+			if i == 0 {
+				prefix = "   * -"
+			}
+			cell = strings.Replace(cell, "\n", " ", -1)
+			if cell == "" {
+				fmt.Fprintf(buf, "%s\n", prefix)
+			} else {
+				fmt.Fprintf(buf, "%s %s\n", prefix, cell)
+			}
+		}
+	}
+	fmt.Fprintf(buf, "\n")
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newDocsTestApp() *App {
+	return &App{
+		Name:     "app",
+		Usage:    "demo app",
+		Examples: "app build -o out",
+		Flags: []*Flag{
+			{Name: "o, output", Usage: "output file | dir", Placeholder: "file", DefValue: "a.out", EnvVar: "APP_OUTPUT"},
+			{Name: "v, verbose", Usage: "verbose output", IsBool: true, Persistent: true},
+		},
+		Commands: []*Command{
+			{
+				Name:  "build, b",
+				Usage: "build project",
+				Arguments: []*Argument{
+					{Name: "TARGET", Usage: "build target", Required: true},
+				},
+				Commands: []*Command{
+					{Name: "all", Usage: "build all"},
+				},
+			},
+			{Name: "internal", Hidden: true},
+		},
+	}
+}
+
+func TestGenMarkdown(t *testing.T) {
+	buf := new(bytes.Buffer)
+	err := newDocsTestApp().GenMarkdown(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := buf.String()
+	wants := []string{
+		"# app\n\ndemo app\n",
+		"## Synopsis\n\n```\napp [global options] COMMAND [command options] [arguments ...]\n```\n",
+		"| `-o, --output file` | output file \\| dir | `a.out` | `APP_OUTPUT` |\n",
+		"| [build, b](app-build.md) | build project |\n",
+		"## Examples\n\n```\napp build -o out\n```\n",
+		"## See Also\n\n* [app build](app-build.md) - build project\n",
+	}
+	for _, want := range wants {
+		if !strings.Contains(doc, want) {
+			t.Errorf("markdown does not contain: %q", want)
+		}
+	}
+	if strings.Contains(doc, "internal") {
+		t.Error("markdown contains hidden command")
+	}
+}
+
+func TestGenMarkdownTree(t *testing.T) {
+	dir := t.TempDir()
+	err := newDocsTestApp().GenMarkdownTree(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.md"))
+	if len(files) != 3 {
+		t.Fatalf("wrong markdown files: %v", files)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "app-build.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := string(data)
+	wants := []string{
+		"| `TARGET` | build target | yes |\n",
+		"* [app](app.md) - demo app\n* [app build all](app-build-all.md) - build all\n",
+		"## Inherited Options\n\n| Option | Description | Default | Env |\n| ------ | ----------- | ------- | --- |\n| `-v, --verbose` | verbose output |  |  |\n",
+	}
+	for _, want := range wants {
+		if !strings.Contains(doc, want) {
+			t.Errorf("markdown does not contain: %q", want)
+		}
+	}
+}
+
+func TestGenReSTTree(t *testing.T) {
+	dir := t.TempDir()
+	err := newDocsTestApp().GenReSTTree(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "app.rst"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := string(data)
+	wants := []string{
+		"app\n===\n\ndemo app\n",
+		"   * - ``-o, --output file``\n     - output file | dir\n     - ``a.out``\n     - ``APP_OUTPUT``\n",
+		"   * - `build, b <app-build.rst>`__\n     - build project\n",
+		".. code-block:: bash\n\n   app build -o out\n",
+	}
+	for _, want := range wants {
+		if !strings.Contains(doc, want) {
+			t.Errorf("rst does not contain: %q", want)
+		}
+	}
+
+	if _, err := ioutil.ReadFile(filepath.Join(dir, "app-build-all.rst")); err != nil {
+		t.Error(err)
+	}
+}
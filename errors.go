@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ExitCoder is the interface of error with an exit code,
+// the code is used as process exit code by app.Run()
+type ExitCoder interface {
+	error
+	ExitCode() int
+}
+
+// ExitError is an error with an exit code
+type ExitError struct {
+	Err  error
+	Code int
+}
+
+// NewExitError creates an error with message and exit code
+func NewExitError(message string, code int) *ExitError {
+	return &ExitError{
+		Err:  errors.New(message),
+		Code: code,
+	}
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return ""
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code
+func (e *ExitError) ExitCode() int {
+	return e.Code
+}
+
+// UsageError is returned when the command line arguments are invalid
+// for the app/command, e.g. unrecognized option or no such command.
+type UsageError struct {
+	Name string // app/command full name
+	Err  error
+}
+
+func (e *UsageError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *UsageError) Unwrap() error {
+	return e.Err
+}
+
+// CommandNotFoundError is returned when the proper command cannot be found
+type CommandNotFoundError struct {
+	Name        string
+	Suggestions []string // similar command names
+}
+
+func (e *CommandNotFoundError) Error() string {
+	return formatSuggestions("no such command: "+e.Name, e.Suggestions)
+}
+
+// UnknownFlagError is returned when the flag is not defined
+type UnknownFlagError struct {
+	Name        string   // name with prefix, e.g. --name
+	Suggestions []string // similar flag names with prefix
+}
+
+func (e *UnknownFlagError) Error() string {
+	return formatSuggestions(fmt.Sprintf("unrecognized option '%s'", e.Name), e.Suggestions)
+}
+
+// RequiredFlagsError is returned when the required flags are not set
+type RequiredFlagsError struct {
+	Names []string
+}
+
+func (e *RequiredFlagsError) Error() string {
+	if len(e.Names) == 1 {
+		return fmt.Sprintf("required option '%s' is not set", e.Names[0])
+	}
+	return fmt.Sprintf("required options '%s' are not set", strings.Join(e.Names, "', '"))
+}
+
+// PanicError is returned when panic in app.Action() and command.Action()
+type PanicError struct {
+	Value interface{}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%v", e.Value)
+}
+
+// FlagNotFoundError is returned by Context.GetXxxE() if the flag is not declared
+type FlagNotFoundError struct {
+	Name string
+}
+
+func (e *FlagNotFoundError) Error() string {
+	return fmt.Sprintf("flag is not declared: %s", e.Name)
+}
+
+// FlagValueError is returned by Context.GetXxxE() if the flag value is invalid for the type
+type FlagValueError struct {
+	Name string
+	Err  error
+}
+
+func (e *FlagValueError) Error() string {
+	return fmt.Sprintf("invalid value of flag '%s': %v", e.Name, e.Err)
+}
+
+func (e *FlagValueError) Unwrap() error {
+	return e.Err
+}
+
+func exitCode(err error) int {
+	var exitCoder ExitCoder
+	if errors.As(err, &exitCoder) {
+		return exitCoder.ExitCode()
+	}
+	return 1
+}
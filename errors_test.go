@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{errors.New("err"), 1},
+		{NewExitError("err", 2), 2},
+		{fmt.Errorf("wrapped: %w", NewExitError("err", 3)), 3},
+		{&UsageError{Err: NewExitError("err", 4)}, 4},
+	}
+
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("exitCode(%v) = %d, want: %d", tt.err, got, tt.want)
+		}
+	}
+}
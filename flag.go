@@ -15,16 +15,24 @@ type Flag struct {
 	Usage       string // help message
 	Placeholder string // placeholder in usage
 	Hidden      bool   // allow flags to be hidden from help/usage text
+	Required    bool   // the flag must be set in cli args, environ or config file
+	Persistent  bool   // the flag is inherited by all subcommands
 
 	IsBool        bool   // if the flag is bool value
+	Negatable     bool   // if the bool flag can be set to false by --no-NAME
+	Count         bool   // if the flag counts the occurrences, e.g. -vvv, Value must be nil or *int
 	DefValue      string // default value (as text); for usage message
 	NoOptDefValue string // default value (as text); if the flag is on the command line without any options
 	EnvVar        string // default value load from environ
 
 	Value interface{} // returns final value
 
-	wrapValue Value // returns final value, wrapped Flag.Value
-	visited   bool  // If the user set the value
+	// Complete returns the candidates of flag value for shell completion,
+	// a candidate can be "value" or "value\tdescription"
+	Complete func(c *Context, toComplete string) []string
+
+	wrapValue Value      // returns final value, wrapped Flag.Value
+	source    FlagSource // where the value came from
 }
 
 // Value is the interface to the dynamic value stored in a flag.
@@ -34,74 +42,95 @@ type Value interface {
 	Set(string) error
 }
 
+// newValue wraps a pointer of base type as Value, returns nil if unsupported
+func newValue(value interface{}) Value {
+	switch val := value.(type) {
+	case *bool:
+		return &boolValue{val}
+	case *string:
+		return &stringValue{val}
+	case *[]string:
+		return &stringSliceValue{val}
+	case *int:
+		return &intValue{val}
+	case *[]int:
+		return &intSliceValue{val}
+	case *int8:
+		return &int8Value{val}
+	case *int16:
+		return &int16Value{val}
+	case *int32:
+		return &int32Value{val}
+	case *int64:
+		return &int64Value{val}
+	case *uint:
+		return &uintValue{val}
+	case *[]uint:
+		return &uintSliceValue{val}
+	case *uint8:
+		return &uint8Value{val}
+	case *uint16:
+		return &uint16Value{val}
+	case *uint32:
+		return &uint32Value{val}
+	case *uint64:
+		return &uint64Value{val}
+	case *float32:
+		return &float32Value{val}
+	case *float64:
+		return &float64Value{val}
+	case *[]float64:
+		return &float64SliceValue{val}
+	case *time.Time:
+		return &timeValue{val}
+	case *time.Duration:
+		return &timeDurationValue{val}
+	case *time.Location:
+		return &timeLocationValue{val}
+	case *net.IP:
+		return &ipValue{val}
+	case *[]net.IP:
+		return &ipSliceValue{val}
+	case *net.IPMask:
+		return &ipMaskValue{val}
+	case *net.IPNet:
+		return &ipNetValue{val}
+	case *[]net.IPNet:
+		return &ipNetSliceValue{val}
+	case *url.URL:
+		return &urlValue{val}
+	case *[]url.URL:
+		return &urlSliceValue{val}
+	case Value:
+		return val
+	default:
+		return nil
+	}
+}
+
 func (f *Flag) initialize() {
-	if f.Value != nil {
-		switch val := f.Value.(type) {
-		case *bool:
+	if f.Count {
+		val, ok := f.Value.(*int)
+		if f.Value == nil {
+			val, ok = new(int), true
+		}
+		if !ok {
+			panic(fmt.Sprintf("unsupported type of counter flag.Value: %T", f.Value))
+		}
+		f.wrapValue = &counterValue{val}
+	}
+
+	if f.Value != nil && !f.Count {
+		if _, ok := f.Value.(*bool); ok {
 			f.IsBool = true
-			f.wrapValue = &boolValue{val}
-		case *string:
-			f.wrapValue = &stringValue{val}
-		case *[]string:
-			f.wrapValue = &stringSliceValue{val}
-		case *int:
-			f.wrapValue = &intValue{val}
-		case *[]int:
-			f.wrapValue = &intSliceValue{val}
-		case *int8:
-			f.wrapValue = &int8Value{val}
-		case *int16:
-			f.wrapValue = &int16Value{val}
-		case *int32:
-			f.wrapValue = &int32Value{val}
-		case *int64:
-			f.wrapValue = &int64Value{val}
-		case *uint:
-			f.wrapValue = &uintValue{val}
-		case *[]uint:
-			f.wrapValue = &uintSliceValue{val}
-		case *uint8:
-			f.wrapValue = &uint8Value{val}
-		case *uint16:
-			f.wrapValue = &uint16Value{val}
-		case *uint32:
-			f.wrapValue = &uint32Value{val}
-		case *uint64:
-			f.wrapValue = &uint64Value{val}
-		case *float32:
-			f.wrapValue = &float32Value{val}
-		case *float64:
-			f.wrapValue = &float64Value{val}
-		case *[]float64:
-			f.wrapValue = &float64SliceValue{val}
-		case *time.Time:
-			f.wrapValue = &timeValue{val}
-		case *time.Duration:
-			f.wrapValue = &timeDurationValue{val}
-		case *time.Location:
-			f.wrapValue = &timeLocationValue{val}
-		case *net.IP:
-			f.wrapValue = &ipValue{val}
-		case *[]net.IP:
-			f.wrapValue = &ipSliceValue{val}
-		case *net.IPMask:
-			f.wrapValue = &ipMaskValue{val}
-		case *net.IPNet:
-			f.wrapValue = &ipNetValue{val}
-		case *[]net.IPNet:
-			f.wrapValue = &ipNetSliceValue{val}
-		case *url.URL:
-			f.wrapValue = &urlValue{val}
-		case *[]url.URL:
-			f.wrapValue = &urlSliceValue{val}
-		case Value:
-			f.wrapValue = val
-		default:
+		}
+		f.wrapValue = newValue(f.Value)
+		if f.wrapValue == nil {
 			panic(fmt.Sprintf("unknown type of flag.Value: %T", f.Value))
 		}
 	}
 
-	if f.Value == nil {
+	if f.Value == nil && !f.Count {
 		if f.IsBool {
 			f.wrapValue = &boolValue{new(bool)}
 		} else {
@@ -113,21 +142,20 @@ func (f *Flag) initialize() {
 		f.Placeholder = "value"
 	}
 
-	envSet := false
+	f.source = FlagSource{} // reset
 	for _, name := range strings.Split(f.EnvVar, ",") {
 		name = strings.TrimSpace(name)
 		if value, ok := os.LookupEnv(name); ok {
 			f.wrapValue.Set(value)
-			envSet = true
+			f.source = FlagSource{Kind: SourceEnv, Name: name}
 			break
 		}
 	}
 
-	if !envSet && f.DefValue != "" {
+	if f.source.Kind == SourceNone && f.DefValue != "" {
 		f.wrapValue.Set(f.DefValue)
+		f.source = FlagSource{Kind: SourceDefault}
 	}
-
-	f.visited = false // reset
 }
 
 // Names returns the names including short names and aliases
@@ -141,15 +169,61 @@ func (f *Flag) Names() []string {
 
 // SetValue sets the value of the named flag
 func (f *Flag) SetValue(value string) error {
-	f.visited = true
+	f.source = FlagSource{Kind: SourceCommandLine}
 	return f.wrapValue.Set(value)
 }
 
+// increase increases the value of counter flag
+func (f *Flag) increase() {
+	f.source = FlagSource{Kind: SourceCommandLine}
+	f.wrapValue.(*counterValue).increase()
+}
+
+// resetSlice clears the slice value set by default value,
+// so the values from value sources replace it instead of appending to it
+func (f *Flag) resetSlice() {
+	switch v := f.wrapValue.(type) {
+	case *stringSliceValue:
+		*v.val = nil
+	case *intSliceValue:
+		*v.val = nil
+	case *uintSliceValue:
+		*v.val = nil
+	case *float64SliceValue:
+		*v.val = nil
+	case *ipSliceValue:
+		*v.val = nil
+	case *ipNetSliceValue:
+		*v.val = nil
+	case *urlSliceValue:
+		*v.val = nil
+	}
+}
+
 // GetValue returns the string value of flag
 func (f *Flag) GetValue() string {
 	return f.wrapValue.String()
 }
 
+// takesValue returns true if the flag requires a value in cli args
+func (f *Flag) takesValue() bool {
+	if _, ok := f.Value.(*bool); ok {
+		return false
+	}
+	return !f.IsBool && !f.Count
+}
+
+// displayName returns the name with prefix for messages, prefer long name
+func (f *Flag) displayName() string {
+	names := f.Names()
+	for _, name := range names {
+		if len(name) > 1 {
+			return "--" + name
+		}
+	}
+	return "-" + names[0]
+}
+
 func lookupFlag(flags []*Flag, name string) *Flag {
 	for _, f := range flags {
 		for _, n := range f.Names() {
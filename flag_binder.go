@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// BindFlags generates flags from the fields of struct which v points to.
+// The field type must be supported by Flag.Value, and the field is described by tags:
+//
+//	cli:"name,n"          names of flag, "-" to skip the field
+//	usage:"..."           help message
+//	env:"NAME"            environ to load value
+//	default:"..."         default value
+//	placeholder:"..."     placeholder in usage
+//	hidden:"true"         hidden from help
+//	required:"true"       required flag
+//	persistent:"true"     inherited by subcommands
+//
+// A pointer field like *time.Location is allocated if it is nil, but a Value
+// like *EnumValue must be initialized before binding.
+//
+// The fields of nested struct are bound recursively, their long names are
+// prefixed by the name of struct field if it has a cli tag, e.g. "db-host".
+func BindFlags(v interface{}) []*Flag {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("BindFlags requires a pointer to struct: %T", v))
+	}
+	return bindStructFlags(rv.Elem(), "")
+}
+
+func bindStructFlags(rv reflect.Value, prefix string) []*Flag {
+	var flags []*Flag
+
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag := field.Tag.Get("cli")
+		if tag == "-" || field.PkgPath != "" {
+			continue // skipped or unexported
+		}
+
+		fv := rv.Field(i)
+		flagValue := fv.Addr().Interface()
+		value := newValue(flagValue)
+
+		// pointer of supported type, e.g. *time.Location or *EnumValue
+		if value == nil && tag != "" && fv.Kind() == reflect.Ptr && newValue(fv.Interface()) != nil {
+			if fv.IsNil() {
+				if _, ok := fv.Interface().(Value); ok {
+					panic(fmt.Sprintf("nil value of field %s.%s: %s", rt.Name(), field.Name, field.Type))
+				}
+				fv.Set(reflect.New(fv.Type().Elem()))
+			}
+			flagValue = fv.Interface()
+			value = newValue(flagValue)
+		}
+
+		// nested struct
+		if value == nil && isStructField(fv) {
+			nested := fv
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					nested = reflect.New(fv.Type().Elem())
+				}
+				nested = nested.Elem()
+			}
+			nestedPrefix := prefix
+			if tag != "" {
+				nestedPrefix = prefix + strings.TrimSpace(tag) + "-"
+			}
+			nestedFlags := bindStructFlags(nested, nestedPrefix)
+			if len(nestedFlags) == 0 {
+				if tag != "" {
+					panic(fmt.Sprintf("unsupported type of field %s.%s: %s", rt.Name(), field.Name, field.Type))
+				}
+				continue
+			}
+			if fv.Kind() == reflect.Ptr && fv.IsNil() {
+				fv.Set(nested.Addr()) // allocate only if it has flags
+			}
+			flags = append(flags, nestedFlags...)
+			continue
+		}
+
+		if tag == "" {
+			continue
+		}
+		if value == nil {
+			panic(fmt.Sprintf("unsupported type of field %s.%s: %s", rt.Name(), field.Name, field.Type))
+		}
+
+		names := strings.Split(tag, ",")
+		for i, name := range names {
+			name = strings.TrimSpace(name)
+			if len(name) > 1 {
+				name = prefix + name
+			}
+			names[i] = name
+		}
+
+		flags = append(flags, &Flag{
+			Name:        strings.Join(names, ", "),
+			Usage:       field.Tag.Get("usage"),
+			Placeholder: field.Tag.Get("placeholder"),
+			Hidden:      tagBool(field, "hidden"),
+			Required:    tagBool(field, "required"),
+			Persistent:  tagBool(field, "persistent"),
+			DefValue:    field.Tag.Get("default"),
+			EnvVar:      field.Tag.Get("env"),
+			Value:       flagValue,
+		})
+	}
+
+	return flags
+}
+
+func isStructField(fv reflect.Value) bool {
+	if fv.Kind() == reflect.Ptr {
+		return fv.Type().Elem().Kind() == reflect.Struct
+	}
+	return fv.Kind() == reflect.Struct
+}
+
+func tagBool(field reflect.StructField, key string) bool {
+	value := field.Tag.Get(key)
+	if value == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		panic(fmt.Sprintf("invalid bool tag %s:%q of field %s", key, value, field.Name))
+	}
+	return b
+}
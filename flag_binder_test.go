@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type bindTestOptions struct {
+	Name    string        `cli:"n,name" usage:"user name" env:"GO_CLI_TEST_NAME" placeholder:"user"`
+	Port    int           `cli:"port" default:"8080"`
+	Debug   bool          `cli:"debug" hidden:"true"`
+	Timeout time.Duration `cli:"timeout" default:"10s"`
+	Tags    []string      `cli:"tags"`
+	Skip    string        `cli:"-"`
+	NoTag   string
+	DB      struct {
+		Host string `cli:"H,host" required:"true"`
+	} `cli:"db"`
+	Log *struct {
+		Level string `cli:"log-level" default:"info"`
+	}
+}
+
+func TestBindFlags(t *testing.T) {
+	opts := &bindTestOptions{}
+	flags := BindFlags(opts)
+
+	var names []string
+	for _, f := range flags {
+		names = append(names, f.Name)
+	}
+	want := []string{"n, name", "port", "debug", "timeout", "tags", "H, db-host", "log-level"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("names = %q, want %q", names, want)
+	}
+
+	f := flags[0]
+	if f.Usage != "user name" || f.EnvVar != "GO_CLI_TEST_NAME" || f.Placeholder != "user" {
+		t.Errorf("wrong flag: %+v", f)
+	}
+	if !flags[2].Hidden || !flags[5].Required {
+		t.Error("wrong hidden or required")
+	}
+
+	app := &App{
+		Name:   "app",
+		Flags:  flags,
+		Action: func(c *Context) {},
+	}
+	err := app.RunE([]string{"app", "-n", "guest", "--debug", "--tags=a", "--tags=b", "-H", "localhost", "--timeout", "1m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if opts.Name != "guest" || opts.Port != 8080 || !opts.Debug || opts.Timeout != time.Minute {
+		t.Errorf("wrong options: %+v", opts)
+	}
+	if !reflect.DeepEqual(opts.Tags, []string{"a", "b"}) {
+		t.Errorf("wrong tags: %q", opts.Tags)
+	}
+	if opts.DB.Host != "localhost" || opts.Log.Level != "info" {
+		t.Errorf("wrong nested options: %+v, %+v", opts.DB, opts.Log)
+	}
+}
+
+func TestBindFlagsPointerValue(t *testing.T) {
+	opts := &struct {
+		Loc    *time.Location `cli:"tz"`
+		Format *EnumValue     `cli:"format" default:"json"`
+		Client *struct {
+			Timeout time.Duration
+		}
+	}{
+		Format: NewEnumValue(nil, "json", "yaml"),
+	}
+	flags := BindFlags(opts)
+
+	var names []string
+	for _, f := range flags {
+		names = append(names, f.Name)
+	}
+	if want := []string{"tz", "format"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("names = %q, want %q", names, want)
+	}
+	if opts.Client != nil {
+		t.Error("struct without flags should not be allocated")
+	}
+
+	app := &App{
+		Name:   "app",
+		Flags:  flags,
+		Action: func(c *Context) {},
+	}
+	if err := app.RunE([]string{"app", "--tz", "Asia/Shanghai", "--format", "yaml"}); err != nil {
+		t.Fatal(err)
+	}
+	if opts.Loc.String() != "Asia/Shanghai" || opts.Format.String() != "yaml" {
+		t.Errorf("wrong options: %v, %v", opts.Loc, opts.Format)
+	}
+}
+
+func TestBindFlagsPanic(t *testing.T) {
+	cases := []interface{}{
+		bindTestOptions{},
+		&struct {
+			C chan int `cli:"c"`
+		}{},
+		&struct {
+			S string `cli:"s" hidden:"yes"`
+		}{},
+		&struct {
+			Format *EnumValue `cli:"format"` // nil value without choices
+		}{},
+		&struct {
+			Client *struct {
+				Timeout time.Duration
+			} `cli:"client"` // no flags in tagged struct
+		}{},
+	}
+	for _, v := range cases {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("no panic for %T", v)
+				}
+			}()
+			BindFlags(v)
+		}()
+	}
+}
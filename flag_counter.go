@@ -0,0 +1,27 @@
+package cli
+
+import (
+	"strconv"
+)
+
+type counterValue struct {
+	val *int
+}
+
+func (v *counterValue) Set(value string) error {
+	val, err := strconv.ParseInt(value, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	*v.val = int(val)
+	return nil
+}
+
+func (v *counterValue) String() string {
+	return strconv.FormatInt(int64(*v.val), 10)
+}
+
+func (v *counterValue) increase() {
+	*v.val++
+}
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EnumValue is a string value which must be one of choices,
+// it can be created by NewEnumValue() or &EnumValue{Choices: ...}
+type EnumValue struct {
+	Choices         []string
+	CaseInsensitive bool // accept the choices in any case, the value is set as declared in Choices
+
+	val *string
+}
+
+// NewEnumValue returns an EnumValue which stores the value into val, val may be nil
+func NewEnumValue(val *string, choices ...string) *EnumValue {
+	if val == nil {
+		val = new(string)
+	}
+	return &EnumValue{Choices: choices, val: val}
+}
+
+func (v *EnumValue) Set(value string) error {
+	for _, choice := range v.Choices {
+		if choice == value || (v.CaseInsensitive && strings.EqualFold(choice, value)) {
+			if v.val == nil {
+				v.val = new(string)
+			}
+			*v.val = choice
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid value '%s', must be one of: %s", value, strings.Join(v.Choices, ", "))
+}
+
+func (v *EnumValue) String() string {
+	if v.val == nil {
+		return ""
+	}
+	return *v.val
+}
+
+// choices returns the choices if the flag value is an EnumValue
+func (f *Flag) choices() []string {
+	if v, ok := f.wrapValue.(*EnumValue); ok {
+		return v.Choices
+	}
+	return nil
+}
+
+// hasCompletion returns true if the candidates of flag value can be completed
+func (f *Flag) hasCompletion() bool {
+	return f.Complete != nil || len(f.choices()) > 0
+}
+
+// completeValue returns the candidates of flag value by Flag.Complete or choices
+func (f *Flag) completeValue(c *Context, toComplete string) []string {
+	if f.Complete != nil {
+		return f.Complete(c, toComplete)
+	}
+	return f.choices()
+}
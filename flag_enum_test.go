@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEnumValue(t *testing.T) {
+	var format string
+	v := NewEnumValue(&format, "json", "yaml", "table")
+
+	if err := v.Set("yaml"); err != nil || format != "yaml" {
+		t.Errorf("Set is wrong, got: %q, %v", format, err)
+	}
+
+	err := v.Set("JSON")
+	if err == nil || err.Error() != "invalid value 'JSON', must be one of: json, yaml, table" {
+		t.Errorf("wrong error: %v", err)
+	}
+
+	v.CaseInsensitive = true
+	if err := v.Set("JSON"); err != nil || v.String() != "json" {
+		t.Errorf("Set is wrong, got: %q, %v", v.String(), err)
+	}
+
+	// struct literal without val
+	v = &EnumValue{Choices: []string{"json", "yaml"}}
+	if v.String() != "" {
+		t.Errorf("String is wrong, got: %q", v.String())
+	}
+	if err := v.Set("yaml"); err != nil || v.String() != "yaml" {
+		t.Errorf("Set is wrong, got: %q, %v", v.String(), err)
+	}
+}
+
+func TestEnumFlag(t *testing.T) {
+	stdout := new(bytes.Buffer)
+	app := &App{
+		Name:   "app",
+		Stdout: stdout,
+		Flags: []*Flag{
+			{Name: "format", Usage: "output format", Value: &EnumValue{Choices: []string{"json", "yaml", "table"}}, DefValue: "table"},
+		},
+		Action: func(c *Context) {},
+	}
+
+	if err := app.RunE([]string{"app"}); err != nil || app.Flags[0].GetValue() != "table" {
+		t.Errorf("wrong default value: %q, %v", app.Flags[0].GetValue(), err)
+	}
+
+	err := app.RunE([]string{"app", "--format", "xml"})
+	if err == nil || !strings.Contains(err.Error(), "must be one of: json, yaml, table") {
+		t.Errorf("wrong error: %v", err)
+	}
+
+	// help
+	if err := app.RunE([]string{"app", "--help"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stdout.String(), "output format (choices: json, yaml, table) (default: table)") {
+		t.Errorf("wrong help:\n%s", stdout.String())
+	}
+
+	// completion
+	stdout.Reset()
+	if err := app.RunE([]string{"app", "__complete", "--format", "y"}); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "yaml\n:1\n" {
+		t.Errorf("wrong candidates: %q", stdout.String())
+	}
+}
@@ -0,0 +1,185 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FlagGroupKind is the kind of constraint of flag group
+type FlagGroupKind int
+
+const (
+	// FlagGroupExclusive means at most one of flags can be set
+	FlagGroupExclusive FlagGroupKind = iota
+	// FlagGroupAtLeastOne means at least one of flags must be set
+	FlagGroupAtLeastOne
+	// FlagGroupAllOrNone means all or none of flags must be set
+	FlagGroupAllOrNone
+	// FlagGroupRequires means the other flags must be set if the first one is set
+	FlagGroupRequires
+)
+
+// FlagGroup is a constraint of flags, which is validated after parsing.
+// A flag is treated as set if its value came from cli args, environ or config file.
+type FlagGroup struct {
+	Kind  FlagGroupKind
+	Names []string // names of flags
+}
+
+// MutuallyExclusive returns a group which at most one of flags can be set
+func MutuallyExclusive(names ...string) *FlagGroup {
+	return &FlagGroup{Kind: FlagGroupExclusive, Names: names}
+}
+
+// AtLeastOne returns a group which at least one of flags must be set
+func AtLeastOne(names ...string) *FlagGroup {
+	return &FlagGroup{Kind: FlagGroupAtLeastOne, Names: names}
+}
+
+// AllOrNone returns a group which all or none of flags must be set
+func AllOrNone(names ...string) *FlagGroup {
+	return &FlagGroup{Kind: FlagGroupAllOrNone, Names: names}
+}
+
+// Requires returns a group which the required flags must be set if the named flag is set
+func Requires(name string, required ...string) *FlagGroup {
+	return &FlagGroup{Kind: FlagGroupRequires, Names: append([]string{name}, required...)}
+}
+
+// String returns the description of constraint for help
+func (g *FlagGroup) String() string {
+	names := flagGroupLabels(g.Names)
+	switch g.Kind {
+	case FlagGroupExclusive:
+		return fmt.Sprintf("%s are mutually exclusive", strings.Join(names, ", "))
+	case FlagGroupAtLeastOne:
+		return fmt.Sprintf("at least one of %s is required", strings.Join(names, ", "))
+	case FlagGroupAllOrNone:
+		return fmt.Sprintf("%s must be used together", strings.Join(names, ", "))
+	case FlagGroupRequires:
+		return fmt.Sprintf("%s requires %s", names[0], strings.Join(names[1:], ", "))
+	default:
+		return strings.Join(names, ", ")
+	}
+}
+
+// initializeFlagGroups panics if a flag group of app or commands has an undeclared flag name,
+// flags are the flags of app or command including the persistent flags inherited from parents
+func initializeFlagGroups(groups []*FlagGroup, flags []*Flag, commands []*Command) {
+	for _, g := range groups {
+		for _, name := range g.Names {
+			if lookupFlag(flags, name) == nil {
+				panic(fmt.Sprintf("undefined flag in flag group: %s", name))
+			}
+		}
+	}
+
+	var persistent []*Flag
+	for _, f := range flags {
+		if f.Persistent {
+			persistent = append(persistent, f)
+		}
+	}
+	for _, c := range commands {
+		cflags := make([]*Flag, 0, len(c.Flags)+len(persistent))
+		cflags = append(cflags, c.Flags...)
+		cflags = append(cflags, persistent...)
+		initializeFlagGroups(c.FlagGroups, cflags, c.Commands)
+	}
+}
+
+// validate returns a FlagGroupError if the constraint is broken
+func (g *FlagGroup) validate(flags []*Flag) error {
+	var set, unset []string
+	for _, name := range g.Names {
+		f := lookupFlag(flags, name)
+		if f == nil {
+			panic(fmt.Sprintf("undefined flag in flag group: %s", name))
+		}
+		if f.source.Kind > SourceDefault {
+			set = append(set, name)
+		} else {
+			unset = append(unset, name)
+		}
+	}
+
+	broken := false
+	switch g.Kind {
+	case FlagGroupExclusive:
+		broken = len(set) > 1
+	case FlagGroupAtLeastOne:
+		broken = len(set) == 0
+	case FlagGroupAllOrNone:
+		broken = len(set) > 0 && len(unset) > 0
+	case FlagGroupRequires:
+		broken = len(set) > 0 && set[0] == g.Names[0] && len(unset) > 0
+	}
+	if broken {
+		return &FlagGroupError{Group: g, Set: set, Unset: unset}
+	}
+	return nil
+}
+
+// FlagGroupError is returned if the constraint of FlagGroup is broken
+type FlagGroupError struct {
+	Group *FlagGroup
+	Set   []string // names of set flags
+	Unset []string // names of unset flags
+}
+
+func (e *FlagGroupError) Error() string {
+	switch e.Group.Kind {
+	case FlagGroupExclusive:
+		return fmt.Sprintf("options %s cannot be used together", joinFlagGroupLabels(e.Set))
+	case FlagGroupAtLeastOne:
+		return fmt.Sprintf("at least one of options %s is required", joinFlagGroupLabels(e.Unset))
+	case FlagGroupAllOrNone:
+		return fmt.Sprintf("options %s must be used together, missing %s",
+			joinFlagGroupLabels(e.Group.Names), joinFlagGroupLabels(e.Unset))
+	case FlagGroupRequires:
+		return fmt.Sprintf("option %s requires %s",
+			joinFlagGroupLabels(e.Group.Names[:1]), joinFlagGroupLabels(e.Unset))
+	default:
+		return e.Group.String()
+	}
+}
+
+// checkFlagGroups checks the flag groups of context and parent contexts
+func (c *Context) checkFlagGroups() error {
+	for ctx := c; ctx != nil; ctx = ctx.parent {
+		var groups []*FlagGroup
+		if ctx.command != nil {
+			groups = ctx.command.FlagGroups
+		} else if ctx.app != nil {
+			groups = ctx.app.FlagGroups
+		}
+		for _, g := range groups {
+			if err := g.validate(ctx.flags); err != nil {
+				return &UsageError{Name: c.name, Err: err}
+			}
+		}
+	}
+	return nil
+}
+
+// flagGroupLabels returns the names with prefix, e.g. "--file"
+func flagGroupLabels(names []string) []string {
+	labels := make([]string, 0, len(names))
+	for _, name := range names {
+		if len(name) > 1 {
+			labels = append(labels, "--"+name)
+		} else {
+			labels = append(labels, "-"+name)
+		}
+	}
+	return labels
+}
+
+// joinFlagGroupLabels returns the quoted labels, e.g. "'--a', '--b'"
+func joinFlagGroupLabels(names []string) string {
+	labels := flagGroupLabels(names)
+	for i, label := range labels {
+		labels[i] = "'" + label + "'"
+	}
+	return strings.Join(labels, ", ")
+}
@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFlagGroups(t *testing.T) {
+	newApp := func() *App {
+		return &App{
+			Name: "app",
+			Flags: []*Flag{
+				{Name: "f, file"},
+				{Name: "stdin", IsBool: true},
+				{Name: "user"},
+				{Name: "password"},
+				{Name: "host", DefValue: "localhost"},
+				{Name: "port"},
+			},
+			FlagGroups: []*FlagGroup{
+				MutuallyExclusive("file", "stdin"),
+				AtLeastOne("file", "stdin"),
+				Requires("user", "password"),
+				AllOrNone("host", "port"),
+			},
+			Action: func(c *Context) {},
+		}
+	}
+
+	tests := []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"-f", "a"}, ""},
+		{[]string{"--stdin", "--user", "u", "--password", "p"}, ""},
+		{[]string{"--stdin", "--password", "p"}, ""},
+		{[]string{"--stdin", "--host", "h", "--port", "1"}, ""},
+		{[]string{"-f", "a", "--stdin"}, "options '--file', '--stdin' cannot be used together"},
+		{[]string{}, "at least one of options '--file', '--stdin' is required"},
+		{[]string{"--stdin", "--user", "u"}, "option '--user' requires '--password'"},
+		{[]string{"--stdin", "--port", "1"}, "options '--host', '--port' must be used together, missing '--host'"},
+	}
+
+	for _, tt := range tests {
+		err := newApp().RunE(append([]string{"app"}, tt.args...))
+		if tt.err == "" {
+			if err != nil {
+				t.Errorf("%q: unexpected error: %v", tt.args, err)
+			}
+			continue
+		}
+		if _, ok := err.(*UsageError); !ok || !strings.HasSuffix(err.Error(), tt.err) {
+			t.Errorf("%q: got error %v, want %q", tt.args, err, tt.err)
+		}
+	}
+}
+
+func TestFlagGroupsHelp(t *testing.T) {
+	buf := new(bytes.Buffer)
+	app := &App{
+		Name:   "app",
+		Stdout: buf,
+		Flags: []*Flag{
+			{Name: "file"},
+			{Name: "stdin", IsBool: true},
+		},
+		FlagGroups: []*FlagGroup{
+			MutuallyExclusive("file", "stdin"),
+			Requires("file", "stdin"),
+		},
+	}
+
+	if err := app.RunE([]string{"app", "--help"}); err != nil {
+		t.Fatal(err)
+	}
+	want := `
+OPTION CONSTRAINTS:
+   --file, --stdin are mutually exclusive
+   --file requires --stdin
+`
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("help does not contain constraints:\n%s", buf.String())
+	}
+}
+
+func TestFlagGroupsInitializePanic(t *testing.T) {
+	tests := []struct {
+		app   *App
+		panic string
+	}{
+		{
+			&App{
+				Name:       "app",
+				Flags:      []*Flag{{Name: "file"}},
+				FlagGroups: []*FlagGroup{MutuallyExclusive("file", "stdin")},
+			},
+			"undefined flag in flag group: stdin",
+		},
+		{
+			&App{
+				Name: "app",
+				Commands: []*Command{
+					{Name: "run"},
+					{
+						Name:       "build",
+						Flags:      []*Flag{{Name: "output"}},
+						FlagGroups: []*FlagGroup{Requires("output", "dir")},
+					},
+				},
+			},
+			"undefined flag in flag group: dir",
+		},
+	}
+
+	for _, tt := range tests {
+		func() {
+			defer func() {
+				if e := recover(); e != tt.panic {
+					t.Errorf("got panic %v, want %q", e, tt.panic)
+				}
+			}()
+			tt.app.RunE([]string{"app", "run"}) // panic even if not run the command
+		}()
+	}
+
+	// persistent flags of parent can be used in the groups of subcommands
+	app := &App{
+		Name:  "app",
+		Flags: []*Flag{{Name: "verbose", IsBool: true, Persistent: true}},
+		Commands: []*Command{
+			{
+				Name:       "build",
+				Flags:      []*Flag{{Name: "quiet", IsBool: true}},
+				FlagGroups: []*FlagGroup{MutuallyExclusive("verbose", "quiet")},
+				Action:     func(c *Context) {},
+			},
+		},
+	}
+	if err := app.RunE([]string{"app", "build", "--quiet"}); err != nil {
+		t.Fatal(err)
+	}
+}
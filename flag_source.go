@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SourceKind is the kind of place where the flag value came from,
+// the kinds are ordered by precedence
+type SourceKind int
+
+const (
+	// SourceNone means the flag is not set and has no default value
+	SourceNone SourceKind = iota
+	// SourceDefault means the value came from Flag.DefValue
+	SourceDefault
+	// SourceConfig means the value came from config file or other ValueSource
+	SourceConfig
+	// SourceEnv means the value came from environ
+	SourceEnv
+	// SourceCommandLine means the value came from cli args
+	SourceCommandLine
+)
+
+// FlagSource describes where the flag value came from
+type FlagSource struct {
+	Kind SourceKind
+	Name string // name of env var, or name of ValueSource, e.g. config file path
+	Key  string // key in ValueSource, e.g. "build.output"
+}
+
+func (s FlagSource) String() string {
+	switch s.Kind {
+	case SourceDefault:
+		return "default"
+	case SourceConfig:
+		return fmt.Sprintf("config %s: %s", s.Name, s.Key)
+	case SourceEnv:
+		return "env " + s.Name
+	case SourceCommandLine:
+		return "command line"
+	default:
+		return "none"
+	}
+}
+
+// Source returns where the value of named flag came from
+func (c *Context) Source(name string) FlagSource {
+	f, err := c.lookupE(name)
+	c.checkStrict(err)
+	if f != nil {
+		return f.source
+	}
+	return FlagSource{}
+}
+
+// debugFlags dumps the flags to Stderr if app.DebugFlags is set,
+// it runs before validation so the dump is shown even if the flags are wrong
+func (c *Context) debugFlags() {
+	if c.app != nil && c.app.DebugFlags {
+		c.DumpFlags(c.Stderr())
+	}
+}
+
+// DumpFlags writes the value and source of every flag in context and its parents to w,
+// it is helpful to diagnose the misconfiguration
+func (c *Context) DumpFlags(w io.Writer) {
+	var contexts []*Context
+	for ctx := c; ctx != nil; ctx = ctx.parent {
+		contexts = append([]*Context{ctx}, contexts...)
+	}
+
+	dumped := make(map[*Flag]bool)
+	for _, ctx := range contexts {
+		var flags []*Flag
+		for _, f := range ctx.flags {
+			if !dumped[f] {
+				dumped[f] = true // persistent flag is inherited by child context
+				flags = append(flags, f)
+			}
+		}
+		if len(flags) == 0 {
+			continue
+		}
+
+		max := 0
+		for _, f := range flags {
+			if n := len(f.displayName() + "=" + f.GetValue()); n > max {
+				max = n
+			}
+		}
+
+		fmt.Fprintf(w, "%s:\n", ctx.name)
+		for _, f := range flags {
+			label := f.displayName() + "=" + f.GetValue()
+			fmt.Fprintf(w, "   %s%s   (%s)\n", label, strings.Repeat(" ", max-len(label)), f.source)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestContextSource(t *testing.T) {
+	os.Setenv("GO_CLI_TEST_USER", "env")
+	defer os.Unsetenv("GO_CLI_TEST_USER")
+
+	stderr := new(bytes.Buffer)
+	var sources map[string]string
+	app := &App{
+		Name:       "app",
+		Stderr:     stderr,
+		DebugFlags: true,
+		ValueSources: []ValueSource{
+			NewMapValueSource("app.json", map[string]interface{}{
+				"build": map[string]interface{}{"jobs": "4"},
+			}),
+		},
+		Flags: []*Flag{
+			{Name: "user", EnvVar: "GO_CLI_TEST_NONE, GO_CLI_TEST_USER"},
+			{Name: "v, verbose", Count: true, Persistent: true},
+		},
+		Commands: []*Command{
+			{
+				Name: "build",
+				Flags: []*Flag{
+					{Name: "o, output", DefValue: "a.out"},
+					{Name: "jobs"},
+					{Name: "target"},
+				},
+				Action: func(c *Context) {
+					sources = make(map[string]string)
+					for _, name := range []string{"user", "verbose", "output", "jobs", "target", "unknown"} {
+						sources[name] = c.Source(name).String()
+					}
+				},
+			},
+		},
+	}
+
+	if err := app.RunE([]string{"app", "build", "-vv"}); err != nil {
+		t.Fatal(err)
+	}
+
+	wants := map[string]string{
+		"user":    "none", // not inherited
+		"verbose": "command line",
+		"output":  "default",
+		"jobs":    "config app.json: build.jobs",
+		"target":  "none",
+		"unknown": "none",
+	}
+	for name, want := range wants {
+		if sources[name] != want {
+			t.Errorf("Source(%q) = %q, want %q", name, sources[name], want)
+		}
+	}
+
+	want := `app:
+   --user=env        (env GO_CLI_TEST_USER)
+   --verbose=2       (command line)
+   --help=false      (none)
+   --version=false   (none)
+app build:
+   --output=a.out   (default)
+   --jobs=4         (config app.json: build.jobs)
+   --target=        (none)
+   --help=false     (none)
+`
+	if stderr.String() != want {
+		t.Errorf("wrong dump:\n%s\nwant:\n%s", stderr.String(), want)
+	}
+}
+
+func TestDebugFlagsBeforeValidation(t *testing.T) {
+	stderr := new(bytes.Buffer)
+	app := &App{
+		Name:       "app",
+		Stderr:     stderr,
+		DebugFlags: true,
+		Commands: []*Command{
+			{
+				Name: "build",
+				Flags: []*Flag{
+					{Name: "jobs", Required: true},
+				},
+				Action: func(c *Context) {
+					t.Error("action should not run")
+				},
+			},
+		},
+	}
+
+	err := app.RunE([]string{"app", "build"})
+	var requiredErr *RequiredFlagsError
+	if !errors.As(err, &requiredErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `app:
+   --help=false      (none)
+   --version=false   (none)
+app build:
+   --jobs=        (none)
+   --help=false   (none)
+`
+	if stderr.String() != want {
+		t.Errorf("wrong dump:\n%s\nwant:\n%s", stderr.String(), want)
+	}
+}
@@ -34,11 +34,26 @@ AUTHORS:
 COMMANDS:
 {{- range .VisibleCommandsUsageLines}}
    {{.}}
+{{- end}}{{end}}{{if .Arguments}}
+
+ARGUMENTS:
+{{- range .ArgumentsUsageLines}}
+   {{.}}
 {{- end}}{{end}}{{if .VisibleFlags}}
 
 {{if .VisibleCommands }}GLOBALS {{end}}OPTIONS:
 {{- range .VisibleFlagsUsageLines}}
    {{.}}
+{{- end}}{{end}}{{if .VisibleInheritedFlags}}
+
+INHERITED OPTIONS:
+{{- range .InheritedFlagsUsageLines}}
+   {{.}}
+{{- end}}{{end}}{{if .FlagGroups}}
+
+OPTION CONSTRAINTS:
+{{- range .FlagGroups}}
+   {{.}}
 {{- end}}{{end}}{{if .ExampleLines}}
 
 EXAMPLES:
@@ -55,9 +70,6 @@ Run '{{.Name}} COMMAND --help' for more information on a command.{{end}}
 
 `
 
-// helpWriter variable for testing hook
-var helpWriter io.Writer = os.Stdout
-
 // HelpContext is a struct for output help
 type HelpContext struct {
 	Name        string
@@ -70,6 +82,14 @@ type HelpContext struct {
 	SeeAlso     string
 	Flags       []*Flag
 	Commands    []*Command
+	Arguments   []*Argument
+	FlagGroups  []*FlagGroup
+
+	// Persistent flags inherited from app and parent commands
+	InheritedFlags []*Flag
+
+	// Writer to output help, defaults to os.Stdout
+	Writer io.Writer
 }
 
 func newAppHelpContext(name string, app *App) *HelpContext {
@@ -84,10 +104,17 @@ func newAppHelpContext(name string, app *App) *HelpContext {
 		SeeAlso:     app.SeeAlso,
 		Flags:       app.Flags,
 		Commands:    app.Commands,
+		Arguments:   app.Arguments,
+		FlagGroups:  app.FlagGroups,
+		Writer:      app.Stdout,
 	}
 }
 
 func newCommandHelpContext(name string, cmd *Command, app *App) *HelpContext {
+	var w io.Writer
+	if app != nil {
+		w = app.Stdout
+	}
 	return &HelpContext{
 		Name:        name,
 		Usage:       cmd.Usage,
@@ -97,6 +124,9 @@ func newCommandHelpContext(name string, cmd *Command, app *App) *HelpContext {
 		SeeAlso:     cmd.SeeAlso,
 		Flags:       cmd.Flags,
 		Commands:    cmd.Commands,
+		Arguments:   cmd.Arguments,
+		FlagGroups:  cmd.FlagGroups,
+		Writer:      w,
 	}
 }
 
@@ -116,6 +146,17 @@ func (c *HelpContext) VisibleFlags() []*Flag {
 	return flags
 }
 
+// VisibleInheritedFlags returns inherited flags which are visible
+func (c *HelpContext) VisibleInheritedFlags() []*Flag {
+	flags := make([]*Flag, 0, len(c.InheritedFlags))
+	for _, f := range c.InheritedFlags {
+		if !f.Hidden {
+			flags = append(flags, f)
+		}
+	}
+	return flags
+}
+
 // VisibleCommands returns commands which are visible
 func (c *HelpContext) VisibleCommands() []*Command {
 	commands := make([]*Command, 0, len(c.Commands))
@@ -144,7 +185,15 @@ func (c *HelpContext) UsageTextLines() []string {
 					usage = usage + "[command options] "
 				}
 			}
-			usage = usage + "[arguments ...]"
+			if len(c.Arguments) > 0 {
+				labels := make([]string, 0, len(c.Arguments))
+				for _, a := range c.Arguments {
+					labels = append(labels, makeArgumentLabel(a))
+				}
+				usage = usage + strings.Join(labels, " ")
+			} else {
+				usage = usage + "[arguments ...]"
+			}
 		}
 		c.UsageText = usage
 	}
@@ -196,8 +245,26 @@ func (c *HelpContext) SeeAlsoLines() []string {
 
 // VisibleFlagsUsageLines splits line for flags
 func (c *HelpContext) VisibleFlagsUsageLines() []string {
-	flags := c.VisibleFlags()
+	return makeFlagsUsageLines(c.VisibleFlags())
+}
 
+// InheritedFlagsUsageLines splits line for inherited flags
+func (c *HelpContext) InheritedFlagsUsageLines() []string {
+	return makeFlagsUsageLines(c.VisibleInheritedFlags())
+}
+
+// commandInheritedFlags returns the persistent flags which are inherited by the subcommands of c
+func (c *HelpContext) commandInheritedFlags() []*Flag {
+	var flags []*Flag
+	for _, f := range append(append([]*Flag{}, c.Flags...), c.InheritedFlags...) {
+		if f.Persistent {
+			flags = append(flags, f)
+		}
+	}
+	return flags
+}
+
+func makeFlagsUsageLines(flags []*Flag) []string {
 	// long flag is indent if short flag is exists.
 	longIndent := false
 outer:
@@ -222,15 +289,32 @@ outer:
 	usageLines := make([]string, 0, len(flags))
 	for _, f := range flags {
 		label := makeFlagLabel(f, longIndent)
-		usage := f.Usage
+		usage := makeFlagUsage(f)
 		whitespaces := strings.Repeat(" ", max-len(label))
-		if f.DefValue != "" {
-			usage = usage + " (default: " + f.DefValue + ")"
+		line := fmt.Sprintf("%s%s   %s", label, whitespaces, usage)
+		usageLines = append(usageLines, line)
+	}
+	return usageLines
+}
+
+// ArgumentsUsageLines splits line for arguments
+func (c *HelpContext) ArgumentsUsageLines() []string {
+	// calc max width for argument name
+	max := 0
+	for _, a := range c.Arguments {
+		if len(a.Name) > max {
+			max = len(a.Name)
 		}
-		if f.EnvVar != "" {
-			usage = usage + " (Env: " + f.EnvVar + ")"
+	}
+
+	usageLines := make([]string, 0, len(c.Arguments))
+	for _, a := range c.Arguments {
+		usage := a.Usage
+		whitespaces := strings.Repeat(" ", max-len(a.Name))
+		if a.Required {
+			usage = usage + " (required)"
 		}
-		line := fmt.Sprintf("%s%s   %s", label, whitespaces, usage)
+		line := fmt.Sprintf("%s%s   %s", a.Name, whitespaces, usage)
 		usageLines = append(usageLines, line)
 	}
 	return usageLines
@@ -262,7 +346,7 @@ func makeFlagLabel(f *Flag, longIndent bool) string {
 	names := f.Names()
 
 	value := ""
-	if !f.IsBool {
+	if !f.IsBool && !f.Count {
 		if f.NoOptDefValue != "" {
 			value = " [" + f.Placeholder + "]"
 		} else {
@@ -274,6 +358,9 @@ func makeFlagLabel(f *Flag, longIndent bool) string {
 	for _, name := range names {
 		label := "-" + name
 		if len(name) > 1 {
+			if f.IsBool && f.Negatable {
+				label = "-[no-]" + name
+			}
 			label = "-" + label
 		}
 		labels = append(labels, label)
@@ -287,6 +374,33 @@ func makeFlagLabel(f *Flag, longIndent bool) string {
 	return str
 }
 
+func makeFlagUsage(f *Flag) string {
+	usage := makeFlagDescription(f)
+	if f.DefValue != "" {
+		usage = usage + " (default: " + f.DefValue + ")"
+	}
+	if f.EnvVar != "" {
+		usage = usage + " (Env: " + f.EnvVar + ")"
+	}
+	return usage
+}
+
+// makeFlagDescription returns the usage of flag without default value and env,
+// which are rendered in their own columns of docs
+func makeFlagDescription(f *Flag) string {
+	usage := f.Usage
+	if f.Count {
+		usage = usage + " (repeatable)"
+	}
+	if f.Required {
+		usage = usage + " (required)"
+	}
+	if choices := f.choices(); len(choices) > 0 {
+		usage = usage + " (choices: " + strings.Join(choices, ", ") + ")"
+	}
+	return usage
+}
+
 func makeCommandLabel(c *Command) string {
 	return strings.Join(c.Names(), ", ")
 }
@@ -296,31 +410,40 @@ func showHelp(c *HelpContext) {
 	if err != nil {
 		panic(err)
 	}
-	err = tmpl.Execute(helpWriter, c)
+	w := c.Writer
+	if w == nil {
+		w = os.Stdout
+	}
+	err = tmpl.Execute(w, c)
 	if err != nil {
 		panic(err)
 	}
 }
 
 func showVersion(app *App) {
-	fmt.Fprintf(helpWriter, "Name:       %s\n", app.Name)
-	fmt.Fprintf(helpWriter, "Version:    %s\n", app.Version)
+	w := app.Stdout
+	if w == nil {
+		w = os.Stdout
+	}
+
+	fmt.Fprintf(w, "Name:       %s\n", app.Name)
+	fmt.Fprintf(w, "Version:    %s\n", app.Version)
 
 	if build := app.BuildInfo; build != nil {
 		if build.GitRevCount != "" {
-			fmt.Fprintf(helpWriter, "Patches:    %s\n", build.GitRevCount)
+			fmt.Fprintf(w, "Patches:    %s\n", build.GitRevCount)
 		}
 		if build.GitBranch != "" {
-			fmt.Fprintf(helpWriter, "Git branch: %s\n", build.GitBranch)
+			fmt.Fprintf(w, "Git branch: %s\n", build.GitBranch)
 		}
 		if build.GitCommit != "" {
-			fmt.Fprintf(helpWriter, "Git commit: %s\n", build.GitCommit)
+			fmt.Fprintf(w, "Git commit: %s\n", build.GitCommit)
 		}
 		if build.Timestamp != "" {
-			fmt.Fprintf(helpWriter, "Built:      %s\n", build.Timestamp)
+			fmt.Fprintf(w, "Built:      %s\n", build.Timestamp)
 		}
 	}
 
-	fmt.Fprintf(helpWriter, "Go version: %s\n", runtime.Version())
-	fmt.Fprintf(helpWriter, "OS/Arch:    %s/%v\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(w, "Go version: %s\n", runtime.Version())
+	fmt.Fprintf(w, "OS/Arch:    %s/%v\n", runtime.GOOS, runtime.GOARCH)
 }
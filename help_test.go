@@ -2,6 +2,8 @@ package cli
 
 import (
 	"bytes"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -9,6 +11,7 @@ func TestHelpShowVersion(t *testing.T) {
 	app := &App{
 		Name:    "app",
 		Version: "1.2.3",
+		Stdout:  new(bytes.Buffer),
 		BuildInfo: &BuildInfo{
 			Timestamp:   "Sat May 13 19:53:08 UTC 2017",
 			GitBranch:   "master",
@@ -17,14 +20,12 @@ func TestHelpShowVersion(t *testing.T) {
 		},
 	}
 
-	// reset
-	helpWriter = new(bytes.Buffer)
-
 	showVersion(app)
 }
 
 func TestHelpShowHelp(t *testing.T) {
 	app := NewApp()
+	app.Stdout = new(bytes.Buffer)
 	app.Name = "app"
 	app.Version = "1.1.1"
 	app.Usage = "demo app"
@@ -64,12 +65,90 @@ func TestHelpShowHelp(t *testing.T) {
 	app.SeeAlso = `https://github.com/subchen
 https://github.com/yingzhuo`
 
-	// reset
-	helpWriter = new(bytes.Buffer)
-
 	ctx1 := newAppHelpContext("app", app)
 	showHelp(ctx1)
 
 	ctx2 := newCommandHelpContext("app build", app.Commands[0], app)
 	showHelp(ctx2)
 }
+
+func TestHelpFlagLabel(t *testing.T) {
+	tests := []struct {
+		flag *Flag
+		want string
+	}{
+		{&Flag{Name: "o, output", Placeholder: "file"}, "-o, --output file"},
+		{&Flag{Name: "debug", IsBool: true}, "    --debug"},
+		{&Flag{Name: "c, color", IsBool: true, Negatable: true}, "-c, --[no-]color"},
+		{&Flag{Name: "v, verbose", Count: true}, "-v, --verbose"},
+	}
+
+	for _, tt := range tests {
+		tt.flag.initialize()
+		if got := makeFlagLabel(tt.flag, true); got != tt.want {
+			t.Errorf("makeFlagLabel() = %q, want: %q", got, tt.want)
+		}
+	}
+}
+
+func TestHelpArguments(t *testing.T) {
+	ctx := &HelpContext{
+		Name: "cp",
+		Arguments: []*Argument{
+			{Name: "SRC", Usage: "source file", Required: true},
+			{Name: "DEST", Usage: "target file"},
+			{Name: "FILES", Usage: "more files", Variadic: true},
+		},
+	}
+
+	if got := ctx.UsageTextLines(); !reflect.DeepEqual(got, []string{"SRC [DEST] [FILES...]"}) {
+		t.Errorf("UsageTextLines() is wrong, got: %q", got)
+	}
+
+	want := []string{
+		"SRC     source file (required)",
+		"DEST    target file",
+		"FILES   more files",
+	}
+	if got := ctx.ArgumentsUsageLines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ArgumentsUsageLines() is wrong, got: %q", got)
+	}
+}
+
+func TestHelpInheritedFlags(t *testing.T) {
+	buf := new(bytes.Buffer)
+	app := &App{
+		Name:   "app",
+		Stdout: buf,
+		Flags: []*Flag{
+			{Name: "d, debug", Usage: "enable debug mode", IsBool: true, Persistent: true},
+			{Name: "secret", IsBool: true, Persistent: true, Hidden: true},
+		},
+		Commands: []*Command{
+			{
+				Name: "build",
+				Flags: []*Flag{
+					{Name: "o, output", Usage: "output file", Persistent: true},
+				},
+				Commands: []*Command{
+					{Name: "all", Action: func(c *Context) {}},
+				},
+			},
+		},
+	}
+
+	if err := app.RunE([]string{"app", "build", "all", "--help"}); err != nil {
+		t.Fatal(err)
+	}
+	want := `
+INHERITED OPTIONS:
+   -o, --output value   output file
+   -d, --debug          enable debug mode
+`
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("help does not contain inherited options:\n%s", buf.String())
+	}
+	if strings.Contains(buf.String(), "secret") {
+		t.Error("help contains hidden inherited option")
+	}
+}
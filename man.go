@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GenManPages writes the man pages of app and all visible subcommands into dir,
+// the files are named like "app.1" and "app-command.1"
+func (a *App) GenManPages(dir string) error {
+	return a.walkHelpContexts(func(ctx, parent *HelpContext) error {
+		return writeDocFile(filepath.Join(dir, manPageName(ctx.Name)+".1"), func(w io.Writer) error {
+			return genManPage(w, ctx, a, parent)
+		})
+	})
+}
+
+// GenManPage writes the man page of app to w
+func (a *App) GenManPage(w io.Writer) error {
+	a.initialize()
+	return genManPage(w, newAppHelpContext(a.Name, a), a, nil)
+}
+
+func genManPage(w io.Writer, ctx *HelpContext, app *App, parent *HelpContext) error {
+	buf := new(bytes.Buffer)
+	name := manPageName(ctx.Name)
+
+	fmt.Fprintf(buf, ".TH \"%s\" \"1\" \"%s\" \"%s\" \"User Commands\"\n",
+		roffEscape(strings.ToUpper(name)), manDate(app), roffEscape(strings.TrimSpace(app.Name+" "+app.Version)))
+
+	fmt.Fprintf(buf, ".SH NAME\n")
+	if ctx.Usage != "" {
+		fmt.Fprintf(buf, "%s \\- %s\n", roffEscape(name), roffEscape(ctx.Usage))
+	} else {
+		fmt.Fprintf(buf, "%s\n", roffEscape(name))
+	}
+
+	fmt.Fprintf(buf, ".SH SYNOPSIS\n")
+	for i, usage := range ctx.UsageTextLines() {
+		if i > 0 {
+			fmt.Fprintf(buf, ".br\n")
+		}
+		fmt.Fprintf(buf, ".B %s\n%s\n", roffEscape(ctx.Name), roffEscape(usage))
+	}
+
+	if description := strings.TrimSpace(ctx.Description); description != "" {
+		fmt.Fprintf(buf, ".SH DESCRIPTION\n")
+		for _, line := range strings.Split(description, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				fmt.Fprintf(buf, ".PP\n")
+			} else {
+				fmt.Fprintf(buf, "%s\n", roffEscape(line))
+			}
+		}
+	}
+
+	if len(ctx.Arguments) > 0 {
+		fmt.Fprintf(buf, ".SH ARGUMENTS\n")
+		for _, a := range ctx.Arguments {
+			usage := a.Usage
+			if a.Required {
+				usage = usage + " (required)"
+			}
+			fmt.Fprintf(buf, ".TP\n.B %s\n%s\n", roffEscape(a.Name), roffEscape(usage))
+		}
+	}
+
+	manFlagsSection(buf, "OPTIONS", ctx.VisibleFlags())
+	manFlagsSection(buf, "INHERITED OPTIONS", ctx.VisibleInheritedFlags())
+
+	if commands := ctx.VisibleCommands(); len(commands) > 0 {
+		fmt.Fprintf(buf, ".SH COMMANDS\n")
+		for _, c := range commands {
+			page := manPageName(ctx.Name + " " + c.Names()[0])
+			fmt.Fprintf(buf, ".TP\n.B %s\n%s\n", roffEscape(makeCommandLabel(c)), roffEscape(c.Usage))
+			fmt.Fprintf(buf, "See \\fB%s\\fR(1).\n", roffEscape(page))
+		}
+	}
+
+	if examples := ctx.ExampleLines(); len(examples) > 0 {
+		fmt.Fprintf(buf, ".SH EXAMPLES\n")
+		fmt.Fprintf(buf, ".PP\n.RS\n.nf\n")
+		for _, line := range examples {
+			fmt.Fprintf(buf, "%s\n", roffEscape(line))
+		}
+		fmt.Fprintf(buf, ".fi\n.RE\n")
+	}
+
+	if authors := ctx.AuthorLines(); len(authors) > 0 {
+		fmt.Fprintf(buf, ".SH AUTHORS\n")
+		for i, line := range authors {
+			if i > 0 {
+				fmt.Fprintf(buf, ".br\n")
+			}
+			fmt.Fprintf(buf, "%s\n", roffEscape(line))
+		}
+	}
+
+	var seeAlso []string
+	if parent != nil {
+		seeAlso = append(seeAlso, fmt.Sprintf("\\fB%s\\fR(1)", roffEscape(manPageName(parent.Name))))
+	}
+	for _, c := range ctx.VisibleCommands() {
+		page := manPageName(ctx.Name + " " + c.Names()[0])
+		seeAlso = append(seeAlso, fmt.Sprintf("\\fB%s\\fR(1)", roffEscape(page)))
+	}
+	if len(seeAlso) > 0 || len(ctx.SeeAlsoLines()) > 0 {
+		fmt.Fprintf(buf, ".SH SEE ALSO\n")
+		if len(seeAlso) > 0 {
+			fmt.Fprintf(buf, "%s\n", strings.Join(seeAlso, ", "))
+		}
+		for _, line := range ctx.SeeAlsoLines() {
+			fmt.Fprintf(buf, ".br\n%s\n", roffEscape(line))
+		}
+	}
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func manFlagsSection(buf *bytes.Buffer, title string, flags []*Flag) {
+	if len(flags) == 0 {
+		return
+	}
+	fmt.Fprintf(buf, ".SH %s\n", title)
+	for _, f := range flags {
+		fmt.Fprintf(buf, ".TP\n.B %s\n%s\n", roffEscape(makeFlagLabel(f, false)), roffEscape(makeFlagUsage(f)))
+	}
+}
+
+// manPageName returns the name of man page, e.g. "app build" -> "app-build"
+func manPageName(name string) string {
+	return strings.Join(strings.Fields(name), "-")
+}
+
+// manDate returns the date of man page from BuildInfo.Timestamp,
+// or SOURCE_DATE_EPOCH for reproducible builds, or today
+func manDate(app *App) string {
+	if app.BuildInfo != nil && app.BuildInfo.Timestamp != "" {
+		layouts := []string{time.UnixDate, time.RFC3339, time.RFC1123, time.RFC1123Z, "2006-01-02"}
+		for _, layout := range layouts {
+			if t, err := time.Parse(layout, app.BuildInfo.Timestamp); err == nil {
+				return t.Format("2006-01-02")
+			}
+		}
+		return roffEscape(app.BuildInfo.Timestamp)
+	}
+	if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "" {
+		if sec, err := strconv.ParseInt(epoch, 10, 64); err == nil {
+			return time.Unix(sec, 0).UTC().Format("2006-01-02")
+		}
+	}
+	return time.Now().Format("2006-01-02")
+}
+
+// roffEscape escapes the roff special characters in text
+func roffEscape(s string) string {
+	s = strings.Replace(s, `\`, `\e`, -1)
+	s = strings.Replace(s, "-", `\-`, -1)
+	s = strings.Replace(s, `"`, `\(dq`, -1)
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
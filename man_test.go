@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newManTestApp() *App {
+	return &App{
+		Name:        "app",
+		Version:     "1.2.3",
+		Usage:       "demo app",
+		Description: "A demo app.\n\n.hidden line",
+		Authors:     "Guoqiang Chen <subchen@gmail.com>",
+		Examples:    "app build -o out",
+		BuildInfo: &BuildInfo{
+			Timestamp: "Sat May 13 19:53:08 UTC 2017",
+		},
+		Flags: []*Flag{
+			{Name: "o, output", Usage: "output file", Placeholder: "file", DefValue: "a.out"},
+			{Name: "v, verbose", Usage: "verbose output", IsBool: true, Persistent: true},
+		},
+		Commands: []*Command{
+			{
+				Name:  "build, b",
+				Usage: "build project",
+				Commands: []*Command{
+					{Name: "all", Usage: "build all"},
+				},
+			},
+			{Name: "internal", Hidden: true},
+		},
+	}
+}
+
+func TestGenManPage(t *testing.T) {
+	buf := new(bytes.Buffer)
+	err := newManTestApp().GenManPage(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	page := buf.String()
+	wants := []string{
+		`.TH "APP" "1" "2017-05-13" "app 1.2.3" "User Commands"`,
+		".SH NAME\napp \\- demo app\n",
+		".SH SYNOPSIS\n.B app\n[global options] COMMAND [command options] [arguments ...]\n",
+		".SH DESCRIPTION\nA demo app.\n.PP\n\\&.hidden line\n",
+		".TP\n.B \\-o, \\-\\-output file\noutput file (default: a.out)\n",
+		".TP\n.B build, b\nbuild project\nSee \\fBapp\\-build\\fR(1).\n",
+		".SH EXAMPLES\n.PP\n.RS\n.nf\napp build \\-o out\n.fi\n.RE\n",
+		".SH SEE ALSO\n\\fBapp\\-build\\fR(1)\n",
+	}
+	for _, want := range wants {
+		if !strings.Contains(page, want) {
+			t.Errorf("man page does not contain: %q", want)
+		}
+	}
+	if strings.Contains(page, "internal") {
+		t.Error("man page contains hidden command")
+	}
+}
+
+func TestGenManPages(t *testing.T) {
+	dir := t.TempDir()
+	err := newManTestApp().GenManPages(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.1"))
+	if len(files) != 3 {
+		t.Fatalf("wrong man pages: %v", files)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "app-build-all.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := string(data)
+	if !strings.Contains(page, ".SH NAME\napp\\-build\\-all \\- build all\n") {
+		t.Errorf("wrong NAME section: %s", page)
+	}
+	if !strings.Contains(page, ".SH SEE ALSO\n\\fBapp\\-build\\fR(1)\n") {
+		t.Errorf("wrong SEE ALSO section: %s", page)
+	}
+	if !strings.Contains(page, ".SH INHERITED OPTIONS\n.TP\n.B \\-v, \\-\\-verbose\nverbose output\n") {
+		t.Errorf("wrong INHERITED OPTIONS section: %s", page)
+	}
+}
+
+func TestManDate(t *testing.T) {
+	os.Setenv("SOURCE_DATE_EPOCH", "1494705188")
+	defer os.Unsetenv("SOURCE_DATE_EPOCH")
+
+	if got := manDate(&App{}); got != "2017-05-13" {
+		t.Errorf("wrong date from SOURCE_DATE_EPOCH: %s", got)
+	}
+
+	// BuildInfo.Timestamp is preferred
+	app := &App{BuildInfo: &BuildInfo{Timestamp: "2018-01-02"}}
+	if got := manDate(app); got != "2018-01-02" {
+		t.Errorf("wrong date from BuildInfo: %s", got)
+	}
+}
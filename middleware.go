@@ -0,0 +1,54 @@
+package cli
+
+// ActionFunc is the handler of app/command action
+type ActionFunc func(*Context) error
+
+// Middleware wraps the next action handler, e.g. for timing, logging or auth checks
+type Middleware func(next ActionFunc) ActionFunc
+
+// Use adds middlewares around the actions of app and all commands,
+// the first one is the outermost
+func (a *App) Use(middleware ...Middleware) {
+	a.middlewares = append(a.middlewares, middleware...)
+}
+
+// Use adds middlewares around the actions of command and all its subcommands,
+// the first one is the outermost
+func (c *Command) Use(middleware ...Middleware) {
+	c.middlewares = append(c.middlewares, middleware...)
+}
+
+// middlewares returns the middlewares of app/command
+func (c *Context) middlewares() []Middleware {
+	if c.command != nil {
+		return c.command.middlewares
+	}
+	if c.app != nil {
+		return c.app.middlewares
+	}
+	return nil
+}
+
+// wrapAction wraps the action by middlewares, the ones of parent wrap
+// the ones of children, and recoverPanic is the outermost one
+func (c *Context) wrapAction(action ActionFunc) ActionFunc {
+	for ctx := c; ctx != nil; ctx = ctx.parent {
+		middlewares := ctx.middlewares()
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			action = middlewares[i](action)
+		}
+	}
+	return recoverPanic(action)
+}
+
+// recoverPanic is the default middleware, which converts the panic into PanicError
+func recoverPanic(next ActionFunc) ActionFunc {
+	return func(c *Context) (err error) {
+		defer func() {
+			if e := recover(); e != nil {
+				err = c.handlePanic(e)
+			}
+		}()
+		return next(c)
+	}
+}
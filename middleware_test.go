@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next ActionFunc) ActionFunc {
+			return func(c *Context) error {
+				calls = append(calls, name+" in")
+				err := next(c)
+				calls = append(calls, name+" out")
+				return err
+			}
+		}
+	}
+
+	sub := &Command{
+		Name: "sub",
+		ActionE: func(c *Context) error {
+			calls = append(calls, "action")
+			return nil
+		},
+	}
+	sub.Use(trace("sub"))
+
+	cmd := &Command{
+		Name:     "cmd",
+		Commands: []*Command{sub},
+	}
+	cmd.Use(trace("cmd1"), trace("cmd2"))
+
+	app := &App{
+		Name:     "app",
+		Commands: []*Command{cmd},
+		Action: func(c *Context) {
+			calls = append(calls, "app action")
+		},
+	}
+	app.Use(trace("app"))
+
+	if err := app.RunE([]string{"app", "cmd", "sub"}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"app in", "cmd1 in", "cmd2 in", "sub in",
+		"action",
+		"sub out", "cmd2 out", "cmd1 out", "app out",
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+
+	// middleware of command is not applied to parent
+	calls = nil
+	if err := app.RunE([]string{"app"}); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"app in", "app action", "app out"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+}
+
+func TestMiddlewarePanic(t *testing.T) {
+	authErr := errors.New("permission denied")
+	app := &App{
+		Name: "app",
+		Action: func(c *Context) {
+			t.Error("action is called")
+		},
+	}
+	app.Use(func(next ActionFunc) ActionFunc {
+		return func(c *Context) error {
+			if c.NArg() > 0 {
+				panic("boom")
+			}
+			return authErr
+		}
+	})
+
+	if err := app.RunE([]string{"app"}); err != authErr {
+		t.Errorf("wrong error: %v", err)
+	}
+
+	// panic in middleware is recovered
+	var panicErr *PanicError
+	app.OnActionPanic = func(c *Context, err error) {}
+	if err := app.RunE([]string{"app", "x"}); !errors.As(err, &panicErr) || panicErr.Value != "boom" {
+		t.Errorf("wrong error: %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// notifySignals returns a context which is cancelled on the first SIGINT or SIGTERM,
+// and exits the program on the second signal or after SignalGracePeriod
+func (a *App) notifySignals(parent context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
+
+	ch := make(chan os.Signal, 2)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+
+	done := make(chan struct{})
+	go func() {
+		var sig os.Signal
+		select {
+		case sig = <-ch:
+		case <-done:
+			return
+		}
+		cancel()
+
+		var timeout <-chan time.Time
+		if a.SignalGracePeriod > 0 {
+			timer := time.NewTimer(a.SignalGracePeriod)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+
+		select {
+		case sig = <-ch: // forced by second signal
+		case <-timeout:
+		case <-done:
+			return
+		}
+		a.Exit(signalExitCode(sig))
+	}()
+
+	stop := func() {
+		signal.Stop(ch)
+		close(done)
+		cancel()
+	}
+	return ctx, stop
+}
+
+// signalExitCode returns 128 + signal number as shells do, e.g. 130 for SIGINT
+func signalExitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 1
+}
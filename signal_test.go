@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"os"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type ctxKey struct{}
+
+func sendSignal(t *testing.T, sig os.Signal) {
+	p, err := os.FindProcess(os.Getpid())
+	if err == nil {
+		err = p.Signal(sig)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAppRunContext(t *testing.T) {
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+
+	var values []interface{}
+	app := &App{
+		Name: "app",
+		Before: func(c *Context) error {
+			values = append(values, c.Context().Value(ctxKey{}))
+			return nil
+		},
+		Commands: []*Command{
+			{
+				Name: "cmd",
+				Action: func(c *Context) {
+					values = append(values, c.Context().Value(ctxKey{}))
+				},
+			},
+		},
+	}
+
+	if err := app.RunContext(ctx, []string{"app", "cmd"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 2 || values[0] != "value" || values[1] != "value" {
+		t.Errorf("wrong values: %v", values)
+	}
+
+	// context.Background() is used by RunE
+	c := &Context{}
+	if c.Context() == nil {
+		t.Error("Context() returns nil")
+	}
+}
+
+func TestAppRunSignal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("signal is not supported")
+	}
+
+	app := &App{
+		Name:          "app",
+		HandleSignals: true,
+		ActionE: func(c *Context) error {
+			sendSignal(t, os.Interrupt)
+			select {
+			case <-c.Context().Done():
+				return c.Context().Err()
+			case <-time.After(5 * time.Second):
+				return errors.New("context is not cancelled")
+			}
+		},
+	}
+
+	if err := app.RunE([]string{"app"}); err != context.Canceled {
+		t.Errorf("wrong error: %v", err)
+	}
+}
+
+func TestAppRunSignalGracePeriod(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("signal is not supported")
+	}
+
+	exited := make(chan int, 1)
+	app := &App{
+		Name:              "app",
+		HandleSignals:     true,
+		SignalGracePeriod: 10 * time.Millisecond,
+		Exit: func(code int) {
+			exited <- code
+		},
+		ActionE: func(c *Context) error {
+			sendSignal(t, syscall.SIGTERM)
+			select {
+			case code := <-exited: // action does not return in grace period
+				if code != 128+int(syscall.SIGTERM) {
+					t.Errorf("wrong exit code: %d", code)
+				}
+				return nil
+			case <-time.After(5 * time.Second):
+				return errors.New("not exited")
+			}
+		},
+	}
+
+	if err := app.RunE([]string{"app"}); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"sort"
+	"strings"
+)
+
+// defaultSuggestionDistance is the default max edit distance for suggestions
+const defaultSuggestionDistance = 2
+
+// suggest returns the candidates which are similar to the input,
+// sorted by the edit distance
+func suggest(input string, candidates []string, maxDistance int) []string {
+	type suggestion struct {
+		name     string
+		distance int
+	}
+
+	var suggestions []suggestion
+	seen := make(map[string]bool)
+	for _, c := range candidates {
+		if seen[c] || c == input {
+			continue
+		}
+		seen[c] = true
+
+		d := editDistance(strings.ToLower(input), strings.ToLower(c))
+		if d <= maxDistance || (len(input) > 1 && strings.HasPrefix(c, input)) {
+			suggestions = append(suggestions, suggestion{c, d})
+		}
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].distance < suggestions[j].distance
+	})
+
+	names := make([]string, 0, len(suggestions))
+	for _, s := range suggestions {
+		names = append(names, s.name)
+	}
+	return names
+}
+
+// editDistance returns the Damerau-Levenshtein distance (optimal string alignment) between a and b
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+
+	d := make([][]int, len(s)+1)
+	for i := range d {
+		d[i] = make([]int, len(t)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			d[i][j] = min3(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				if v := d[i-2][j-2] + cost; v < d[i][j] {
+					d[i][j] = v // transposition
+				}
+			}
+		}
+	}
+	return d[len(s)][len(t)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// formatSuggestions appends the suggestions to error message
+func formatSuggestions(msg string, suggestions []string) string {
+	if len(suggestions) == 0 {
+		return msg
+	}
+	if len(suggestions) == 1 {
+		return msg + "\n\nDid you mean this?\n\t" + suggestions[0]
+	}
+	return msg + "\n\nDid you mean one of these?\n\t" + strings.Join(suggestions, "\n\t")
+}
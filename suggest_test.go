@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "abc", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"build", "biuld", 1},
+		{"status", "stats", 1},
+	}
+
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want: %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"build", "release", "remove", "rebuild"}
+
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"biuld", []string{"build"}},
+		{"re", []string{"remove", "release", "rebuild"}},
+		{"remve", []string{"remove"}},
+		{"xxxxxx", []string{}},
+	}
+
+	for _, tt := range tests {
+		if got := suggest(tt.input, candidates, 2); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("suggest(%q) = %q, want: %q", tt.input, got, tt.want)
+		}
+	}
+}